@@ -0,0 +1,162 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// bind.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.04.10 init, named :param placeholders
+// ----------------------------------------------------------------------------------
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// BindType # placeholder style of the driver
+type BindType int
+
+const (
+	// BindQuestion # ? (mysql, firebird)
+	BindQuestion BindType = iota
+	// BindDollar # $1, $2 ..
+	BindDollar
+	// BindAt # @p1, @p2 ..
+	BindAt
+)
+
+// Params # named parameters for :name placeholders
+type Params map[string]interface{}
+
+// ErrMixedParams #
+var ErrMixedParams = errors.New("dbx: mixed positional and named parameters")
+
+// Placeholder # n is 1-based
+func (b BindType) Placeholder(n int) string {
+	switch b {
+	case BindDollar:
+		return "$" + strconv.Itoa(n)
+	case BindAt:
+		return "@p" + strconv.Itoa(n)
+	}
+
+	return "?"
+}
+
+// bindNamed rewrites :name placeholders into the positional style of bt.
+// Statements without named arguments are returned unchanged, so :var
+// references inside Firebird PSQL blocks are left alone.
+func bindNamed(bt BindType, sq string, args []interface{}) (string, []interface{}, error) {
+	named := map[string]interface{}{}
+	positional := 0
+
+	for _, a := range args {
+		switch v := a.(type) {
+		case sql.NamedArg:
+			named[strings.ToLower(v.Name)] = v.Value
+		case Params:
+			for k, x := range v {
+				named[strings.ToLower(k)] = x
+			}
+		default:
+			positional++
+		}
+	}
+
+	if len(named) == 0 {
+		return sq, args, nil
+	}
+
+	if positional > 0 {
+		return sq, args, ErrMixedParams
+	}
+
+	var sb strings.Builder
+	var out []interface{}
+
+	rs := []rune(sq)
+	le := len(rs)
+	for i := 0; i < le; i++ {
+		c := rs[i]
+		switch {
+		case c == '\'' || c == '"':
+			j := skipQuoted(rs, i)
+			sb.WriteString(string(rs[i:j]))
+			i = j - 1
+
+		case c == '-' && i+1 < le && rs[i+1] == '-':
+			j := i
+			for j < le && rs[j] != '\n' {
+				j++
+			}
+			sb.WriteString(string(rs[i:j]))
+			i = j - 1
+
+		case c == '/' && i+1 < le && rs[i+1] == '*':
+			j := i + 2
+			for j < le && !(rs[j] == '/' && rs[j-1] == '*' && j > i+2) {
+				j++
+			}
+			if j < le {
+				j++
+			}
+			sb.WriteString(string(rs[i:j]))
+			i = j - 1
+
+		case c == ':' && i+1 < le && isNameStart(rs[i+1]) && (i == 0 || rs[i-1] != ':'):
+			j := i + 1
+			for j < le && isNameChar(rs[j]) {
+				j++
+			}
+
+			name := string(rs[i+1 : j])
+			val, ok := named[strings.ToLower(name)]
+			if !ok {
+				return sq, args, errors.New("dbx: missing named parameter :" + name)
+			}
+
+			out = append(out, val)
+			sb.WriteString(bt.Placeholder(len(out)))
+			i = j - 1
+
+		default:
+			sb.WriteRune(c)
+		}
+	}
+
+	return sb.String(), out, nil
+}
+
+func skipQuoted(rs []rune, i int) int {
+	qc := rs[i]
+	le := len(rs)
+	i++
+	for i < le {
+		if rs[i] == qc {
+			if i+1 < le && rs[i+1] == qc {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+
+	return le
+}
+
+func isNameStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c rune) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
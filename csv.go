@@ -0,0 +1,164 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// csv.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 raw values, quoted if equal to Null, embedded quotes doubled
+// 2023.05.02 init, RFC 4180 csv/tsv
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// CSVOptions # zero value: comma, double quote, header, NULL as empty unquoted field, \n;
+// values equal to Null, as the empty string by default, are always quoted
+type CSVOptions struct {
+	Delimiter  rune
+	Quote      rune
+	NoHeader   bool
+	Null       string
+	DateFormat string // go layout for DATE, default as delivered (2006-01-02)
+	TimeFormat string // go layout for TIMESTAMP, default as delivered with fractions
+	DecimalSep rune   // e.g. ',' for german spreadsheets
+	BOM        bool   // utf-8 byte order mark
+	LineEnd    string // "\r\n" for strict RFC 4180
+	QuoteAll   bool
+}
+
+func (o *CSVOptions) withDefaults() CSVOptions {
+	c := CSVOptions{}
+	if o != nil {
+		c = *o
+	}
+
+	if c.Delimiter == 0 {
+		c.Delimiter = ','
+	}
+
+	if c.Quote == 0 {
+		c.Quote = '"'
+	}
+
+	if c.LineEnd == "" {
+		c.LineEnd = "\n"
+	}
+
+	return c
+}
+
+func (o *CSVOptions) quote(s string) string {
+	if o.QuoteAll || strings.ContainsAny(s, string(o.Delimiter)+string(o.Quote)+"\r\n") {
+		return o.enclose(s)
+	}
+
+	return s
+}
+
+// enclose # in quotes, embedded quotes doubled
+func (o *CSVOptions) enclose(s string) string {
+	q := string(o.Quote)
+	return q + strings.ReplaceAll(s, q, q+q) + q
+}
+
+// value # formatted, not quoted; false for NULL
+func (o *CSVOptions) value(f *SqxField) (string, bool) {
+	if f.IsNull() {
+		return o.Null, false
+	}
+
+	switch f.Typ {
+	case "DATE":
+		if o.DateFormat != "" {
+			if t, err := f.Time(); err == nil {
+				return t.Format(o.DateFormat), true
+			}
+		}
+		return f.AsString(), true
+
+	case "TIMESTAMP":
+		if o.TimeFormat != "" {
+			if t, err := f.Time(); err == nil {
+				return t.Format(o.TimeFormat), true
+			}
+		}
+
+	case "DECIMAL", "NUMERIC", "DOUBLE", "FLOAT", "REAL":
+		if o.DecimalSep != 0 && o.DecimalSep != '.' {
+			return strings.Replace(string(f.Value), ".", string(o.DecimalSep), 1), true
+		}
+	}
+
+	return string(f.Value), true
+}
+
+func (q *SQLX) csvHeader(o *CSVOptions) string {
+	var sb strings.Builder
+	for i, f := range q.Fields {
+		if i > 0 {
+			sb.WriteRune(o.Delimiter)
+		}
+		sb.WriteString(o.quote(f.Name))
+	}
+
+	return sb.String()
+}
+
+func (q *SQLX) csvLine(o *CSVOptions) string {
+	var sb strings.Builder
+	for i := range q.Fields {
+		if i > 0 {
+			sb.WriteRune(o.Delimiter)
+		}
+
+		s, ok := o.value(&q.Fields[i])
+		if ok && s == o.Null {
+			s = o.enclose(s)
+		} else if ok {
+			s = o.quote(s)
+		}
+		sb.WriteString(s)
+	}
+
+	return sb.String()
+}
+
+// WriteCSV # fetch all rows as csv into w
+func (q *SQLX) WriteCSV(w io.Writer, opts *CSVOptions) error {
+	o := opts.withDefaults()
+	bw := bufio.NewWriter(w)
+
+	if o.BOM {
+		bw.WriteString("\xEF\xBB\xBF")
+	}
+
+	if !o.NoHeader {
+		bw.WriteString(q.csvHeader(&o))
+		bw.WriteString(o.LineEnd)
+	}
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		bw.WriteString(q.csvLine(&o))
+		bw.WriteString(o.LineEnd)
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	return bw.Flush()
+}
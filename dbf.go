@@ -9,15 +9,20 @@ package dbx
 // preservation of copyright and license notices. Licensed works, modifications,
 // and larger works may be distributed under different terms and without source code.
 // ----------------------------------------------------------------------------------
+// 2023.04.28 Prec,Scale, AsFloat64,AsDecimal,AsBool,AsBytes,AsTime,AsDuration
+//            with error reporting variants Int64,Int,Float64,Decimal,Bool,Time,Duration
 // 2022.12.07 (wu) bugfix "-" in prepareIntField
 // ----------------------------------------------------------------------------------
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // SqxField #
@@ -26,6 +31,8 @@ type SqxField struct {
 	Name   string
 	Typ    string
 	Len    int
+	Prec   int
+	Scale  int
 	OutLen int
 	Value  []byte
 	rTyp   reflect.Type
@@ -65,6 +72,138 @@ func (f *SqxField) AsInteger() int {
 	return i
 }
 
+// Int64 # NULL is 0
+func (f *SqxField) Int64() (int64, error) {
+	if f.Value == nil {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(string(f.Value)), 10, 64)
+}
+
+// Int # NULL is 0
+func (f *SqxField) Int() (int, error) {
+	if f.Value == nil {
+		return 0, nil
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(f.Value)))
+}
+
+// Float64 # NULL is 0
+func (f *SqxField) Float64() (float64, error) {
+	if f.Value == nil {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(string(f.Value)), 64)
+}
+
+// AsFloat64 #
+func (f *SqxField) AsFloat64() float64 {
+	x, _ := f.Float64()
+	return x
+}
+
+// Decimal # exact, NULL is 0
+func (f *SqxField) Decimal() (decimal.Decimal, error) {
+	if f.Value == nil {
+		return decimal.Zero, nil
+	}
+
+	return decimal.NewFromString(strings.TrimSpace(string(f.Value)))
+}
+
+// AsDecimal #
+func (f *SqxField) AsDecimal() decimal.Decimal {
+	d, _ := f.Decimal()
+	return d
+}
+
+// Bool # 1/0, true/false, t/f, y/n, j/n; NULL is false
+func (f *SqxField) Bool() (bool, error) {
+	if f.Value == nil {
+		return false, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(f.Value))) {
+	case "1", "true", "t", "y", "yes", "j", "ja":
+		return true, nil
+	case "0", "false", "f", "n", "no", "nein":
+		return false, nil
+	}
+
+	return false, errors.New("dbx: bad bool value '" + string(f.Value) + "'")
+}
+
+// AsBool #
+func (f *SqxField) AsBool() bool {
+	b, _ := f.Bool()
+	return b
+}
+
+// AsBytes # raw value, BLOBs untrimmed; NULL is nil
+func (f *SqxField) AsBytes() []byte {
+	if f.Value == nil {
+		return nil
+	}
+
+	return append([]byte{}, f.Value...)
+}
+
+// Time # with fractional seconds and time zone; NULL is the zero time
+func (f *SqxField) Time() (time.Time, error) {
+	if f.Value == nil {
+		return time.Time{}, nil
+	}
+
+	return parseTime(string(f.Value))
+}
+
+// AsTime #
+func (f *SqxField) AsTime() time.Time {
+	t, _ := f.Time()
+	return t
+}
+
+// Duration # [-]h:mm:ss[.fff] (TIME), go duration or seconds; NULL is 0
+func (f *SqxField) Duration() (time.Duration, error) {
+	if f.Value == nil {
+		return 0, nil
+	}
+
+	s := strings.TrimSpace(string(f.Value))
+	if ss := strings.Split(strings.TrimPrefix(s, "-"), ":"); len(ss) == 3 {
+		h, e1 := strconv.Atoi(ss[0])
+		m, e2 := strconv.Atoi(ss[1])
+		sec, e3 := strconv.ParseFloat(ss[2], 64)
+		if e1 == nil && e2 == nil && e3 == nil {
+			d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec*float64(time.Second))
+			if s[0] == '-' {
+				d = -d
+			}
+			return d, nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	sec, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.New("dbx: bad duration value '" + s + "'")
+	}
+
+	return time.Duration(sec * float64(time.Second)), nil
+}
+
+// AsDuration #
+func (f *SqxField) AsDuration() time.Duration {
+	d, _ := f.Duration()
+	return d
+}
+
 // AsString #
 func (f *SqxField) AsString() string {
 	if f.Value == nil {
@@ -9,16 +9,34 @@ package dbx
 // preservation of copyright and license notices. Licensed works, modifications,
 // and larger works may be distributed under different terms and without source code.
 // ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 Exec via ExecContext, ShowLineAsEcv escaped, select of batches, Fetch without rows
+// 2023.05.11 PrintTo sql
+// 2023.05.10 PrintTo harbour
+// 2023.05.08 PrintTo xlsx
+// 2023.05.07 PrintTo markdown,html,xml
+// 2023.05.06 PrintTo table,box via WriteTable
+// 2023.05.05 ShowLineAsEcv title via EcvTitle
+// 2023.05.02 AsDelimitedText,PrintTo csv/tsv via WriteCSV
+// 2023.04.30 AsJSON,PrintTo json via WriteJSON, PrintTo ndjson
+// 2023.04.28 Prec,Scale, scaled integers as DECIMAL
+// 2023.04.20 ignore unbounded Length (text, bytea)
+// 2023.04.18 Typ via Dialect.TypeName
+// 2023.04.16 querier, SQLX runs on DB or Tx
+// 2023.04.14 ExecContext, ctx in execSelect, Fetch sets Err
+// 2023.04.10 ExecArgs with bind args
+// ----------------------------------------------------------------------------------
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"io"
-	"reflect"
-	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 const (
@@ -36,10 +54,18 @@ const (
 	StmtExecProc = 16
 )
 
+// querier # *sql.DB or *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 // SQLX #
 type SQLX struct {
 	r          *sql.Rows
 	db         *DB
+	cn         querier
 	scanValues []interface{}
 	closed     bool
 
@@ -56,13 +82,16 @@ type SQLX struct {
 
 // NewSQLX #new instance
 func NewSQLX(v *DB) *SQLX {
-	return &SQLX{db: v, closed: true, NewLine: true}
+	return &SQLX{db: v, cn: v.DB, closed: true, NewLine: true}
 }
 
 // PrepareStmt Sql.StmtType
 func (q *SQLX) prepareStmt(sqlText string) {
 	q.Statement = sqlText
-	qf := strings.Fields(sqlText)
+	// batches: select after ; is a word of its own
+	qf := strings.FieldsFunc(sqlText, func(r rune) bool {
+		return r == ';' || unicode.IsSpace(r)
+	})
 
 	a := -1
 	q.StmtType = StmtUnknown
@@ -118,8 +147,8 @@ func (q *SQLX) prepareStmt(sqlText string) {
 }
 
 // execSelect #
-func (q *SQLX) execSelect(sq string) bool {
-	q.r, q.Err = q.db.Query(sq)
+func (q *SQLX) execSelect(ctx context.Context, sq string, args ...interface{}) bool {
+	q.r, q.Err = q.cn.QueryContext(ctx, sq, args...)
 	if q.Err != nil {
 		return false
 	}
@@ -139,13 +168,30 @@ func (q *SQLX) execSelect(sq string) bool {
 		f.Q = q
 
 		_len, ok := col.Length()
-		if ok {
+		if ok && _len < 1<<24 {
 			f.Len = int(_len)
 		}
 		f.Name = col.Name()
-		f.Typ = col.DatabaseTypeName()
+		f.Typ = q.db.Dialect.TypeName(col.DatabaseTypeName())
 		f.rTyp = col.ScanType()
 
+		if p, sc, ok := col.DecimalSize(); ok {
+			f.Prec = int(p)
+			// firebird: negative scale
+			if sc < 0 {
+				sc = -sc
+			}
+			f.Scale = int(sc)
+		}
+
+		// firebird: numeric(18,2) is INT64 with scale
+		if f.Scale > 0 {
+			switch f.Typ {
+			case "SHORT", "SMALLINT", "LONG", "INT", "INT64", "BIGINT", "INT128":
+				f.Typ = "DECIMAL"
+			}
+		}
+
 		switch f.Typ {
 		case "TINYINT":
 			f.OutLen = 4
@@ -175,6 +221,15 @@ func (q *SQLX) execSelect(sq string) bool {
 		case "TIMESTAMP":
 			f.OutLen = 19
 
+		case "NUMERIC":
+			f.Typ = "DECIMAL"
+			fallthrough
+		case "DECIMAL":
+			f.OutLen = f.Len
+			if f.Prec > 0 && f.Prec < 40 {
+				f.OutLen = f.Prec + 2
+			}
+
 		default:
 			f.OutLen = f.Len
 		}
@@ -189,12 +244,18 @@ func (q *SQLX) execSelect(sq string) bool {
 	return true
 }
 
-// Fetch Sql
+// Fetch Sql, false without rows
 func (q *SQLX) Fetch() (readed bool) {
+	if q.r == nil {
+		return false
+	}
+
 	readed = q.r.Next()
 	if readed {
 		q.Lfd++
 		q.Err = q.r.Scan(q.scanValues...)
+	} else {
+		q.Err = q.r.Err()
 	}
 
 	return readed
@@ -202,20 +263,29 @@ func (q *SQLX) Fetch() (readed bool) {
 
 // Exec #
 func (q *SQLX) Exec(sq string) bool {
-	//	log.Printf("sq: [%s]", sq)
+	return q.ExecContext(context.Background(), sq)
+}
 
-	q.prepareStmt(sq)
+// ExecArgs # with bind args, ? or :name
+func (q *SQLX) ExecArgs(sq string, args ...interface{}) bool {
+	return q.ExecContext(context.Background(), sq, args...)
+}
 
-	if (q.StmtType & StmtOutf) == StmtOutf {
-		return q.execSelect(sq)
-	}
+// ExecContext # with bind args, the query is canceled with ctx
+func (q *SQLX) ExecContext(ctx context.Context, sq string, args ...interface{}) bool {
+	q.prepareStmt(sq)
 
-	_, q.Err = q.db.Exec(sq)
+	sq, args, q.Err = bindNamed(q.db.Dialect.Bind(), sq, args)
 	if q.Err != nil {
 		return false
 	}
 
-	return true
+	if (q.StmtType & StmtOutf) == StmtOutf {
+		return q.execSelect(ctx, sq, args...)
+	}
+
+	_, q.Err = q.cn.ExecContext(ctx, sq, args...)
+	return q.Err == nil
 }
 
 // Close Sql
@@ -263,49 +333,14 @@ func (q *SQLX) ShowLine(isTitle bool) string {
 	return s
 }
 
-// ShowLineAsEcv QLIne As ecv
+// ShowLineAsEcv QLIne As ecv, escaped as EcvLine, Replacer is applied before escaping
 func (q *SQLX) ShowLineAsEcv(isTitle bool) string {
 	s := ""
 
 	if isTitle {
-		s = "@" + q.QName
-		for _, f := range q.Fields {
-			s = s + "," + f.Name
-			switch f.Typ {
-			case "VARYING", "VARCHAR":
-				s = s + "[char_" + strconv.Itoa(f.Len) + "]"
-			case "SHORT":
-				s = s + "[short]"
-			case "INT", "LONG":
-				s = s + "[int]"
-			case "BIGINT", "INT64":
-				s = s + "[bigint]"
-			case "TIMESTAMP", "DATETIME":
-				s = s + "[timestamp]"
-			case "DATE":
-				s = s + "[date]"
-			case "TEXT":
-				if f.Len > 0 {
-					s = s + "[char_" + strconv.Itoa(f.Len) + "]"
-				} else {
-					s = s + "[str]"
-				}
-			default:
-				s = s + "[str]"
-			}
-		}
+		s = q.EcvTitle("")
 	} else {
-		for i, f := range q.Fields {
-			if i > 0 {
-				s = s + "^"
-			}
-
-			if q.Replacer != nil {
-				s = s + q.Replacer.Replace(f.AsString())
-			} else {
-				s = s + f.AsString()
-			}
-		}
+		s = q.ecvLine(q.Replacer)
 	}
 
 	if q.NewLine {
@@ -315,66 +350,37 @@ func (q *SQLX) ShowLineAsEcv(isTitle bool) string {
 	return s
 }
 
-// AsDelimitedText #
+// AsDelimitedText # csv lines with the first rune of delimiter, header first
 func (q *SQLX) AsDelimitedText(delimiter string) ([]string, error) {
+	o := (&CSVOptions{Delimiter: []rune(delimiter + ";")[0]}).withDefaults()
 
-	var ss []string
-
-	s := ""
-	for _, f := range q.Fields {
-		s = s + f.Name + delimiter
-	}
-	ss = append(ss, s)
-
+	ss := []string{q.csvHeader(&o)}
 	for q.Fetch() {
 		if q.Err != nil {
 			return nil, q.Err
 		}
 
-		s = ""
-		for _, f := range q.Fields {
-			s = s + string(f.Value) + delimiter
-		}
-		ss = append(ss, s)
+		ss = append(ss, q.csvLine(&o))
+	}
+
+	if q.Err != nil {
+		return nil, q.Err
 	}
 
 	return ss, nil
 }
 
-// AsJSON #
+// AsJSON # all rows as indented json array, closes q
 func (q *SQLX) AsJSON() ([]byte, error) {
-	// an array of JSON objects
-	// the map key is the field name
-	var objects []map[string]interface{}
-
-	for q.Fetch() {
-		if q.Err != nil {
-			return nil, q.Err
-		}
-
-		values := make([]interface{}, q.ColNum)
-		object := map[string]interface{}{}
-		for i, f := range q.Fields {
-			v := reflect.New(f.rTyp).Interface()
-			switch v.(type) {
-			case *int64:
-				ii := f.AsInt64()
-				object[f.Name] = ii
-			case *int32, *int16:
-				ii := f.AsInteger()
-				object[f.Name] = ii
-			default:
-				ss := f.AsString()
-				object[f.Name] = ss
-			}
+	var b bytes.Buffer
 
-			values[i] = object[f.Name]
-		}
-
-		objects = append(objects, object)
-	}
+	err := q.WriteJSON(&b, &JSONOptions{Indent: "\t"})
 	q.Close()
-	return json.MarshalIndent(objects, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
 }
 
 // PrintOut #
@@ -411,56 +417,51 @@ func (q *SQLX) PrintTo(w io.Writer, frm string) (err error) {
 	*/
 
 	if frm == "json" {
-		b, _ := q.AsJSON()
-		if q.Err != nil {
-			fmt.Fprintf(w, "asJSON: %v\n", q.Err)
-			return q.Err
-		}
+		return q.WriteJSON(w, &JSONOptions{Indent: "\t"})
+	}
 
-		fmt.Fprintf(w, string(b))
-		return nil
+	if frm == "ndjson" {
+		return q.WriteNDJSON(w, nil)
 	}
 
 	if frm == "table" {
-		s := q.ShowLine(true)
-		fmt.Fprintf(w, "%v", s)
-		for q.Fetch() {
-			if q.Err != nil {
-				return q.Err
-			}
-			s := q.ShowLine(false)
-			fmt.Fprintf(w, "%v", s)
-		}
-		return nil
+		return q.WriteTable(w, nil)
 	}
 
-	delimiter := ";"
-	s := ""
-	for i, f := range q.Fields {
-		if i > 0 {
-			s = s + delimiter
-		}
-		s = s + f.Name
+	if frm == "box" {
+		return q.WriteTable(w, &TableOptions{Border: true})
 	}
-	fmt.Fprintf(w, "%v\n", s)
 
-	for q.Fetch() {
-		if q.Err != nil {
-			return q.Err
-		}
+	if frm == "markdown" || frm == "md" {
+		return q.WriteMarkdown(w)
+	}
 
-		s = ""
-		for i, f := range q.Fields {
-			if i > 0 {
-				s = s + delimiter
-			}
+	if frm == "html" {
+		return q.WriteHTML(w)
+	}
 
-			s = s + string(f.Value)
-		}
-		fmt.Fprintf(w, "%v\n", s)
+	if frm == "xml" {
+		return q.WriteXML(w, nil)
 	}
 
-	return nil
+	if frm == "sql" {
+		return q.WriteInserts(w, "", nil)
+	}
+
+	if frm == "harbour" || frm == "prg" {
+		return q.WriteHarbour(w, nil)
+	}
+
+	if frm == "xlsx" {
+		return q.WriteXLSX(w, nil)
+	}
+
+	if frm == "tsv" {
+		return q.WriteCSV(w, &CSVOptions{Delimiter: '\t'})
+	}
+
+	// csv
+	return q.WriteCSV(w, &CSVOptions{Delimiter: ';'})
 }
 
 // IsNull (columnIdx)
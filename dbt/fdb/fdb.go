@@ -11,29 +11,113 @@ package fdb
 // ----------------------------------------------------------------------------------
 // HISTORY
 // ----------------------------------------------------------------------------------
+// 2023.05.17 DDLAtCommit
+// 2023.05.12 Drop
+// 2023.05.04 Insert,MultiRow
+// 2023.04.18 Dialect, ExistIndex
+// 2023.04.12 Open with error result
+// 2023.04.10 ops with ? binds
 // 2023.04.02 OpExistDom,OpExistExc
 // ----------------------------------------------------------------------------------
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/waldurbas/dbx"
 
 	// firebirdsql #
 	_ "github.com/waldurbas/firebirdsql"
 )
 
-// NewDatabase #new instance
-func NewDatabase(a interface{}) *dbx.DB {
-	db := dbx.NewDB("firebirdsql", a)
+// Dialect #firebird
+type Dialect struct {
+	dbx.BaseDialect
+}
+
+const dom = "RDB$FIELDS where RDB$FIELD_NAME not starting with 'RDB$' and RDB$FIELD_NAME not starting with 'TMP$' and RDB$FIELD_NAME not starting with 'SEC$'"
+
+// Exist #
+func (Dialect) Exist(obj dbx.Object) string {
+	switch obj {
+	case dbx.ObjTable:
+		return `select count(*) from RDB$RELATIONS where RDB$RELATION_NAME=? and RDB$VIEW_BLR is NULL`
+	case dbx.ObjTableCol:
+		return `select count(*) from RDB$RELATION_FIELDS b where b.RDB$RELATION_NAME=? and b.RDB$FIELD_NAME=?`
+	case dbx.ObjIndex:
+		return `select count(*) from RDB$INDICES where RDB$RELATION_NAME=? and RDB$INDEX_NAME=?`
+	case dbx.ObjProc:
+		return `select count(*) from RDB$PROCEDURES where RDB$PROCEDURE_NAME=?`
+	case dbx.ObjFunc:
+		return `select count(*) from RDB$FUNCTIONS where RDB$FUNCTION_NAME=?`
+	case dbx.ObjTrigger:
+		return `select count(*) from RDB$TRIGGERS where RDB$RELATION_NAME=? and RDB$TRIGGER_NAME=?`
+	case dbx.ObjDomain:
+		return `select count(*) from ` + dom + ` and RDB$FIELD_NAME=UPPER(?)`
+	case dbx.ObjException:
+		return `select count(*) from RDB$EXCEPTIONS where RDB$EXCEPTION_NAME=?`
+	}
+
+	return ""
+}
 
-	db.AddOp(dbx.OpExistTable, `select count(*) from RDB$RELATIONS where RDB$RELATION_NAME='%s' and RDB$VIEW_BLR is NULL`)
-	db.AddOp(dbx.OpExistTableCol, `select count(*) from RDB$RELATION_FIELDS b where b.RDB$RELATION_NAME='%s' and b.RDB$FIELD_NAME='%s'`)
-	db.AddOp(dbx.OpExistProc, `select count(*) from RDB$PROCEDURES where RDB$PROCEDURE_NAME='%s'`)
-	db.AddOp(dbx.OpExistFunc, `select count(*) from RDB$FUNCTIONS where RDB$FUNCTION_NAME='%s'`)
-	db.AddOp(dbx.OpExistTrg, `select count(*) from RDB$TRIGGERS where RDB$RELATION_NAME='%s' and RDB$TRIGGER_NAME='%s'`)
+// Limit # rows m to n
+func (Dialect) Limit(sq string, limit int, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return sq
+	}
+
+	if limit <= 0 {
+		return sq + " offset " + strconv.Itoa(offset) + " rows"
+	}
+
+	return sq + " rows " + strconv.Itoa(offset+1) + " to " + strconv.Itoa(offset+limit)
+}
+
+// CallProc # execute procedure
+func (Dialect) CallProc(proc string, n int) string {
+	if n == 0 {
+		return "execute procedure " + proc
+	}
+
+	return "execute procedure " + proc + "(" + dbx.Placeholders(dbx.BindQuestion, n) + ")"
+}
 
-	dom := "RDB$FIELDS where RDB$FIELD_NAME not starting with 'RDB$' and RDB$FIELD_NAME not starting with 'TMP$' and RDB$FIELD_NAME not starting with 'SEC$'"
-	db.AddOp(dbx.OpExistDom, `select count(*) from `+dom+` and RDB$FIELD_NAME=UPPER('%s')`)
-	db.AddOp(dbx.OpExistExc, `select count(*) from RDB$EXCEPTIONS where RDB$EXCEPTION_NAME='%s'`)
+// Insert # update or insert .. matching, one row per statement
+func (Dialect) Insert(table string, cols []string, keys []string, rows [][]string) string {
+	if len(keys) == 0 {
+		return dbx.InsertValues("insert into "+table, cols, rows)
+	}
 
-	return db
+	return dbx.InsertValues("update or insert into "+table, cols, rows) + " matching (" + strings.Join(keys, ",") + ")"
+}
+
+// MultiRow # no values (..),(..) in firebird
+func (Dialect) MultiRow() bool {
+	return false
+}
+
+// Drop # alter table T drop C without column
+func (Dialect) Drop(obj dbx.Object, sName string) string {
+	if obj == dbx.ObjTableCol {
+		tbl, col := dbx.SplitName(obj, sName)
+		return "alter table " + tbl + " drop " + col
+	}
+
+	return dbx.BaseDialect{}.Drop(obj, sName)
+}
+
+// DDLAtCommit # metadata changes are applied at commit
+func (Dialect) DDLAtCommit() bool {
+	return true
+}
+
+// Open #new instance
+func Open(a interface{}) (*dbx.DB, error) {
+	return dbx.OpenDialect("firebirdsql", Dialect{}, a)
+}
+
+// NewDatabase #new instance, exit on error
+func NewDatabase(a interface{}) *dbx.DB {
+	return dbx.Must(Open(a))
 }
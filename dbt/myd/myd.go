@@ -11,6 +11,13 @@ package myd
 // ----------------------------------------------------------------------------------
 // HISTORY
 // ----------------------------------------------------------------------------------
+// 2023.05.16 TxDDL
+// 2023.05.12 Drop
+// 2023.05.11 Literal
+// 2023.05.04 Insert
+// 2023.04.18 Dialect, ExistProc,ExistFunc,ExistTrigger
+// 2023.04.12 Open with error result
+// 2023.04.10 ops with ? binds
 // 2020.06.24 zurück zu eigener Version (fork) meine Änderung ist wieder weg
 // 2020.05.26 meine Änderung 2020.02.22 @Wald.Urbas for strings (x.length >> 2)
 //            wurde vom github.com/go-sql-driver/mysql übernommen
@@ -19,6 +26,7 @@ package myd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/waldurbas/dbx"
@@ -27,18 +35,113 @@ import (
 	_ "github.com/waldurbas/mysql"
 )
 
-// NewDatabase #new instance
-func NewDatabase(a interface{}) *dbx.DB {
-	db := dbx.NewDB("mysql", a)
+// Dialect #mysql
+type Dialect struct {
+	dbx.BaseDialect
+}
+
+// Exist #
+func (Dialect) Exist(obj dbx.Object) string {
+	switch obj {
+	case dbx.ObjTable:
+		return "select count(*) from INFORMATION_SCHEMA.TABLES where TABLE_SCHEMA=database() and TABLE_NAME=?"
+	case dbx.ObjTableCol:
+		return "select count(*) from INFORMATION_SCHEMA.COLUMNS where TABLE_SCHEMA=database() and TABLE_NAME=? and COLUMN_NAME=?"
+	case dbx.ObjIndex:
+		return "select count(*) from INFORMATION_SCHEMA.STATISTICS where TABLE_SCHEMA=database() and TABLE_NAME=? and INDEX_NAME=?"
+	case dbx.ObjProc:
+		return "select count(*) from INFORMATION_SCHEMA.ROUTINES where ROUTINE_SCHEMA=database() and ROUTINE_TYPE='PROCEDURE' and ROUTINE_NAME=?"
+	case dbx.ObjFunc:
+		return "select count(*) from INFORMATION_SCHEMA.ROUTINES where ROUTINE_SCHEMA=database() and ROUTINE_TYPE='FUNCTION' and ROUTINE_NAME=?"
+	case dbx.ObjTrigger:
+		return "select count(*) from INFORMATION_SCHEMA.TRIGGERS where TRIGGER_SCHEMA=database() and EVENT_OBJECT_TABLE=? and TRIGGER_NAME=?"
+	}
+
+	return ""
+}
+
+// Quote # `ident`
+func (Dialect) Quote(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+
+// Limit # offset needs a limit in mysql
+func (Dialect) Limit(sq string, limit int, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return sq
+	}
 
-	db.AddOp(dbx.OpExistTable, "select count(*) from INFORMATION_SCHEMA.TABLES where TABLE_SCHEMA='"+db.Cfg.DBName+"' and TABLE_NAME='%s'")
-	db.AddOp(dbx.OpExistTableCol, "select count(*) from INFORMATION_SCHEMA.COLUMNS where TABLE_SCHEMA='"+db.Cfg.DBName+"' and TABLE_NAME='%s' and COLUMN_NAME='%s'")
-	db.AddOp(dbx.OpExistIdx, "select count(*) from INFORMATION_SCHEMA.STATISTICS where TABLE_SCHEMA='"+db.Cfg.DBName+"' and TABLE_NAME='%s' and INDEX_NAME='%s'")
+	if limit <= 0 {
+		return sq + " limit " + strconv.Itoa(offset) + ",18446744073709551615"
+	}
+
+	return sq + " limit " + strconv.Itoa(offset) + "," + strconv.Itoa(limit)
+}
+
+// TypeName # without UNSIGNED
+func (Dialect) TypeName(dbType string) string {
+	return strings.TrimPrefix(strings.ToUpper(dbType), "UNSIGNED ")
+}
+
+// Insert # on duplicate key update, the keys are the unique keys of table
+func (Dialect) Insert(table string, cols []string, keys []string, rows [][]string) string {
+	sq := dbx.InsertValues("insert into "+table, cols, rows)
+	if len(keys) == 0 {
+		return sq
+	}
+
+	set := dbx.UpdateCols(cols, keys, func(c string) string { return c + "=values(" + c + ")" })
+	if set == "" {
+		// nothing to update, keep the row
+		set = keys[0] + "=" + keys[0]
+	}
+
+	return sq + " on duplicate key update " + set
+}
+
+// mysqlString # backslash is an escape char in mysql strings
+var mysqlString = strings.NewReplacer(`\`, `\\`, "'", "''", "\x00", `\0`)
+
+// Literal #
+func (Dialect) Literal(f *dbx.SqxField) string {
+	return dbx.Literals{String: func(s string) string { return "'" + mysqlString.Replace(s) + "'" }}.Literal(f)
+}
+
+// Drop # drop index .. on table, no domains and exceptions
+func (Dialect) Drop(obj dbx.Object, sName string) string {
+	switch obj {
+	case dbx.ObjIndex:
+		tbl, ix := dbx.SplitName(obj, sName)
+		return "drop index " + ix + " on " + tbl
+	case dbx.ObjDomain, dbx.ObjException:
+		return ""
+	}
+
+	return dbx.BaseDialect{}.Drop(obj, sName)
+}
+
+// TxDDL # ddl commits implicitly
+func (Dialect) TxDDL() bool {
+	return false
+}
+
+// Open #new instance
+func Open(a interface{}) (*dbx.DB, error) {
+	db, err := dbx.OpenDialect("mysql", Dialect{}, a)
+	if err != nil {
+		return nil, err
+	}
 
 	db.Call = Call
-	return db
+	return db, nil
+}
+
+// NewDatabase #new instance, exit on error
+func NewDatabase(a interface{}) *dbx.DB {
+	return dbx.Must(Open(a))
 }
 
+// Call # call proc(@out,..) with OUT parameters
 func Call(db *dbx.DB, sql string) *dbx.SQLX {
 	if strings.Index(sql, "call ") == 0 {
 		sf := strings.FieldsFunc(strings.TrimSpace(sql[5:]), func(r rune) bool {
@@ -56,12 +159,11 @@ func Call(db *dbx.DB, sql string) *dbx.SQLX {
 			sq := `select p.ordinal_position,p.parameter_name,p.data_type,p.character_maximum_length as char_length,p.numeric_precision,p.numeric_scale
 from information_schema.routines r
 join information_schema.parameters p on p.specific_schema = r.routine_schema and p.specific_name = r.specific_name
-where r.routine_schema = Database() and r.routine_type='PROCEDURE' and r.specific_name='` + sf[0] +
-				`' and p.ordinal_position is not NULL and p.PARAMETER_MODE ='OUT' order by 1`
+where r.routine_schema = Database() and r.routine_type='PROCEDURE' and r.specific_name=? and p.ordinal_position is not NULL and p.PARAMETER_MODE ='OUT' order by 1`
 
 			fmt.Println("--> exec ProcFields")
 			pFields := []*ProcField{}
-			q := db.ExecQ(sq)
+			q := db.QueryQ(sq, sf[0])
 			for q.Fetch() {
 				b := &ProcField{Idx: q.AsInteger(0), Name: q.AsString(1), Typ: strings.ToUpper(q.AsString(2)), Len: q.AsInteger(3)}
 				pFields = append(pFields, b)
@@ -0,0 +1,98 @@
+package sqd
+
+// ----------------------------------------------------------------------------------
+// sqd.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.12 Drop
+// 2023.04.22 init
+// ----------------------------------------------------------------------------------
+
+import (
+	"strings"
+
+	"github.com/waldurbas/dbx"
+
+	// sqlite, pure go #
+	_ "modernc.org/sqlite"
+)
+
+// Dialect #sqlite, names are compared case insensitive like sqlite does
+type Dialect struct {
+	dbx.BaseDialect
+}
+
+// DSN # path of the database file or :memory:
+func (Dialect) DSN(c dbx.DBCfg) string {
+	return c.DBName
+}
+
+// Exist # procedures, functions, domains and exceptions do not exist in sqlite
+func (Dialect) Exist(obj dbx.Object) string {
+	switch obj {
+	case dbx.ObjTable:
+		return "select count(*) from sqlite_master where type='table' and upper(name)=upper(?)"
+	case dbx.ObjTableCol:
+		return "select count(*) from sqlite_master m, pragma_table_info(m.name) p where m.type='table' and upper(m.name)=upper(?) and upper(p.name)=upper(?)"
+	case dbx.ObjIndex:
+		return "select count(*) from sqlite_master where type='index' and upper(tbl_name)=upper(?) and upper(name)=upper(?)"
+	case dbx.ObjTrigger:
+		return "select count(*) from sqlite_master where type='trigger' and upper(tbl_name)=upper(?) and upper(name)=upper(?)"
+	}
+
+	return ""
+}
+
+// TypeName # declared type without (len), affinity names as SqxField.Typ
+func (Dialect) TypeName(dbType string) string {
+	t := strings.ToUpper(strings.TrimSpace(dbType))
+	if i := strings.Index(t, "("); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+
+	switch t {
+	case "INTEGER":
+		return "BIGINT"
+	case "REAL", "DOUBLE PRECISION":
+		return "DOUBLE"
+	case "NUMERIC":
+		return "DECIMAL"
+	case "BOOL":
+		return "BOOLEAN"
+	}
+
+	return t
+}
+
+// Drop # no procedures, functions, domains and exceptions
+func (Dialect) Drop(obj dbx.Object, sName string) string {
+	if dbx.ObjectParts(obj) == 1 && obj != dbx.ObjTable {
+		return ""
+	}
+
+	return dbx.BaseDialect{}.Drop(obj, sName)
+}
+
+// Open #new instance, path of the database file or :memory:
+func Open(path string) (*dbx.DB, error) {
+	db, err := dbx.OpenDialect("sqlite", Dialect{}, dbx.DBCfg{DBName: path})
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite serializes writers, :memory: exists per connection
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewDatabase #new instance, exit on error
+func NewDatabase(path string) *dbx.DB {
+	return dbx.Must(Open(path))
+}
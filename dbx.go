@@ -9,12 +9,20 @@ package dbx
 // preservation of copyright and license notices. Licensed works, modifications,
 // and larger works may be distributed under different terms and without source code.
 // ----------------------------------------------------------------------------------
+// 2023.05.17 ExecSqlxContext, AddOp and OpExist* deprecated, kept on Dialect.Exist
+// 2023.04.20 DSN via Dialect
+// 2023.04.18 Dialect replaces dbOp,AddOp and OpExist*, OpenDialect, CallProc
+// 2023.04.16 scanRow,existArgs shared with Tx
+// 2023.04.14 Context variants, ConnectTimeout, IsTimeout, IsCanceled
+// 2023.04.12 Open,Must,Execute,ExecuteQ,ScanI,ScanI64,ScanS with error result
+// 2023.04.10 QueryI,QueryI64,QueryS,QueryQ,QuerySqlx with bind args
 // 2023.04.02 ExistDomain,ExistException
 // ----------------------------------------------------------------------------------
 
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -22,32 +30,54 @@ import (
 	"time"
 )
 
-// OpExistTable #
-const OpExistTable = "existTable"
-
-// OpExistTableCol #
-const OpExistTableCol = "existTableCol"
-
-// OpExistProc #
-const OpExistProc = "existProc"
-
-// OpExistFunc #
-const OpExistFunc = "existFunc"
+// Debug #
+var Debug int
 
-// OpExistIdx #
-const OpExistIdx = "existIdx"
+// ConnectTimeout # for Connect
+var ConnectTimeout = 5 * time.Second
+
+// ErrConStr #
+var ErrConStr = errors.New("dbx: bad conString")
+
+// OpExist* # keys of AddOp
+//
+// Deprecated: the statements are given by Dialect.Exist.
+const (
+	OpExistTable    = "existTable"
+	OpExistTableCol = "existTableCol"
+	OpExistProc     = "existProc"
+	OpExistFunc     = "existFunc"
+	OpExistIdx      = "existIdx"
+	OpExistTrg      = "existTrg"
+	OpExistExc      = "existExc"
+	OpExistDom      = "existDom"
+)
 
-// OpExistTrg #
-const OpExistTrg = "existTrg"
+var opObjects = map[string]Object{
+	OpExistTable:    ObjTable,
+	OpExistTableCol: ObjTableCol,
+	OpExistProc:     ObjProc,
+	OpExistFunc:     ObjFunc,
+	OpExistIdx:      ObjIndex,
+	OpExistTrg:      ObjTrigger,
+	OpExistExc:      ObjException,
+	OpExistDom:      ObjDomain,
+}
 
-// OpExistExc #
-const OpExistExc = "existExc"
+// opDialect # Dialect with the statements of AddOp
+type opDialect struct {
+	Dialect
+	ops map[Object]string
+}
 
-// OpExistDom #
-const OpExistDom = "existDom"
+// Exist # statement of AddOp, if any
+func (d opDialect) Exist(obj Object) string {
+	if sq, ok := d.ops[obj]; ok {
+		return sq
+	}
 
-// Debug #
-var Debug int
+	return d.Dialect.Exist(obj)
+}
 
 // DBCfg #
 type DBCfg struct {
@@ -62,10 +92,10 @@ type DBCfg struct {
 type DB struct {
 	*sql.DB
 	DrvName string
+	Dialect Dialect
 	opened  bool
 	Cfg     DBCfg
 	Err     error
-	dbOp    map[string]string
 	ExitF   func(int)
 	Call    func(*DB, string) *SQLX
 }
@@ -100,11 +130,16 @@ func ConStr2DBCfg(s string) *DBCfg {
 	return nil
 }
 
-// NewDB #new instance
-func NewDB(drvName string, a interface{}) *DB {
+// Open #new instance, a is a conString or DBCfg
+func Open(drvName string, a interface{}) (*DB, error) {
+	return OpenDialect(drvName, BaseDialect{}, a)
+}
+
+// OpenDialect #new instance with the dialect of the driver
+func OpenDialect(drvName string, d Dialect, a interface{}) (*DB, error) {
 	var c *DBCfg
 
-	db := &DB{DrvName: drvName}
+	db := &DB{DrvName: drvName, Dialect: d}
 	switch v := a.(type) {
 	case string:
 		c = ConStr2DBCfg(v)
@@ -113,18 +148,15 @@ func NewDB(drvName string, a interface{}) *DB {
 	}
 
 	if c == nil {
-		fmt.Println("NewDB.error: bad conString")
-		os.Exit(1)
+		return nil, ErrConStr
 	}
 
-	db.dbOp = make(map[string]string)
 	db.Cfg = *c
 
-	cstr := DBCfg2ConStr(db.Cfg)
+	cstr := d.DSN(db.Cfg)
 	db.DB, db.Err = sql.Open(db.DrvName, cstr)
 	if db.Err != nil {
-		fmt.Println("NewDB.error:", db.Err)
-		os.Exit(1)
+		return nil, db.Err
 	}
 
 	db.ExitF = db.exitFunc
@@ -132,9 +164,24 @@ func NewDB(drvName string, a interface{}) *DB {
 	db.SetMaxOpenConns(5)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
+	return db, nil
+}
+
+// Must #exit on Open error, for CLI tools
+func Must(db *DB, err error) *DB {
+	if err != nil {
+		fmt.Println("NewDB.error:", err)
+		os.Exit(1)
+	}
+
 	return db
 }
 
+// NewDB #new instance, exit on error
+func NewDB(drvName string, a interface{}) *DB {
+	return Must(Open(drvName, a))
+}
+
 func lgx(format string, x ...interface{}) {
 	if Debug > 0 {
 		log.Printf(format, x...)
@@ -169,13 +216,28 @@ func (v *DB) Fatal(msg string, err error) {
 
 // Connect to Database
 func (v *DB) Connect() bool {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(context.Background(), ConnectTimeout)
 	defer cancel()
+	return v.ConnectContext(ctx)
+}
+
+// ConnectContext #
+func (v *DB) ConnectContext(ctx context.Context) bool {
 	v.Err = v.DB.PingContext(ctx)
 	v.opened = v.Err == nil
 	return v.opened
 }
 
+// IsTimeout # err caused by an expired deadline
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsCanceled # err caused by a canceled context
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
 // Close #close database
 func (v *DB) Close() {
 	if v.opened {
@@ -184,15 +246,120 @@ func (v *DB) Close() {
 	}
 }
 
-// AddOp #
-func (v *DB) AddOp(key string, val string) {
-	v.dbOp[key] = val
-}
-
 func (v *DB) prepareSqText(format string, x ...interface{}) string {
 	return fmt.Sprintf(format, x...)
 }
 
+// ScanI64 # asInt64 with bind args
+func (v *DB) ScanI64(sq string, args ...interface{}) (int64, error) {
+	return v.ScanI64Context(context.Background(), sq, args...)
+}
+
+// ScanI64Context #
+func (v *DB) ScanI64Context(ctx context.Context, sq string, args ...interface{}) (int64, error) {
+	var n int64
+	err := scanRow(ctx, v.DB, v.Dialect.Bind(), sq, args, &n)
+	return n, err
+}
+
+// ScanI # asInteger with bind args
+func (v *DB) ScanI(sq string, args ...interface{}) (int, error) {
+	return v.ScanIContext(context.Background(), sq, args...)
+}
+
+// ScanIContext #
+func (v *DB) ScanIContext(ctx context.Context, sq string, args ...interface{}) (int, error) {
+	var n int
+	err := scanRow(ctx, v.DB, v.Dialect.Bind(), sq, args, &n)
+	return n, err
+}
+
+// ScanS # asString with bind args
+func (v *DB) ScanS(sq string, args ...interface{}) (string, error) {
+	return v.ScanSContext(context.Background(), sq, args...)
+}
+
+// ScanSContext #
+func (v *DB) ScanSContext(ctx context.Context, sq string, args ...interface{}) (string, error) {
+	var s string
+	err := scanRow(ctx, v.DB, v.Dialect.Bind(), sq, args, &s)
+	return s, err
+}
+
+func scanRow(ctx context.Context, cn querier, bt BindType, sq string, args []interface{}, dst interface{}) error {
+	sq, args, err := bindNamed(bt, sq, args)
+	if err == nil {
+		err = cn.QueryRowContext(ctx, sq, args...).Scan(dst)
+	}
+
+	return err
+}
+
+// QueryI64 # asInt64 with bind args
+func (v *DB) QueryI64(sq string, args ...interface{}) int64 {
+	var n int64
+	n, v.Err = v.ScanI64(sq, args...)
+	if v.Err == nil {
+		return n
+	}
+
+	return 0
+}
+
+// QueryI # asInteger with bind args
+func (v *DB) QueryI(sq string, args ...interface{}) int {
+	var n int
+	n, v.Err = v.ScanI(sq, args...)
+	if v.Err == nil {
+		return n
+	}
+
+	return 0
+}
+
+// QueryS # asString with bind args
+func (v *DB) QueryS(sq string, args ...interface{}) string {
+	var s string
+	s, v.Err = v.ScanS(sq, args...)
+	if v.Err == nil {
+		return s
+	}
+
+	return ""
+}
+
+// QueryQ # with bind args
+func (v *DB) QueryQ(sq string, args ...interface{}) *SQLX {
+	q := NewSQLX(v)
+	q.ExecArgs(sq, args...)
+	return q
+}
+
+// QuerySqlx # with bind args, exit on error
+func (v *DB) QuerySqlx(sq string, args ...interface{}) *SQLX {
+	q, err := v.ExecuteQ(sq, args...)
+	if err != nil {
+		v.Fatal("exec", err)
+	}
+
+	return q
+}
+
+// ExecuteQ # with bind args
+func (v *DB) ExecuteQ(sq string, args ...interface{}) (*SQLX, error) {
+	return v.ExecuteQContext(context.Background(), sq, args...)
+}
+
+// ExecuteQContext #
+func (v *DB) ExecuteQContext(ctx context.Context, sq string, args ...interface{}) (*SQLX, error) {
+	q := NewSQLX(v)
+	if !q.ExecContext(ctx, sq, args...) {
+		return q, q.Err
+	}
+
+	return q, nil
+}
+
 // ExecI64 # asInteger
 func (v *DB) ExecI64(format string, x ...interface{}) int64 {
 	sq := v.prepareSqText(format, x...)
@@ -246,18 +413,15 @@ func (v *DB) CreateSqlx() *SQLX {
 	return NewSQLX(v)
 }
 
-// ExecSqlx #
+// ExecSqlx #exit on error
 func (v *DB) ExecSqlx(format string, x ...interface{}) *SQLX {
-	sq := fmt.Sprintf(format, x...)
+	return v.ExecSqlxContext(context.Background(), format, x...)
+}
 
+// ExecSqlxContext #exit on error
+func (v *DB) ExecSqlxContext(ctx context.Context, format string, x ...interface{}) *SQLX {
 	q := NewSQLX(v)
-	q.prepareStmt(sq)
-	if (q.StmtType & StmtOutf) == StmtOutf {
-		q.execSelect(sq)
-	} else {
-		_, q.Err = q.db.Exec(sq)
-	}
-
+	q.ExecContext(ctx, fmt.Sprintf(format, x...))
 	if q.Err != nil {
 		v.Fatal("exec", q.Err)
 	}
@@ -265,81 +429,137 @@ func (v *DB) ExecSqlx(format string, x ...interface{}) *SQLX {
 	return q
 }
 
-// ExecuteF #
-func (v *DB) ExecuteF(statement string) {
-	_, err := v.DB.Exec(statement)
+// Execute # with bind args
+func (v *DB) Execute(statement string, args ...interface{}) error {
+	return v.ExecuteContext(context.Background(), statement, args...)
+}
+
+// ExecuteContext #
+func (v *DB) ExecuteContext(ctx context.Context, statement string, args ...interface{}) error {
+	return execute(ctx, v.DB, v.Dialect.Bind(), statement, args)
+}
+
+func execute(ctx context.Context, cn querier, bt BindType, statement string, args []interface{}) error {
+	sq, args, err := bindNamed(bt, statement, args)
+	if err == nil {
+		_, err = cn.ExecContext(ctx, sq, args...)
+	}
 
 	if err != nil {
 		le := len(statement)
 		if le > 256 {
 			le = 256
 		}
-		pfx := statement[0:le]
-		fmt.Println("error: db.Execute."+pfx, v.ErrMsg(err))
+		return fmt.Errorf("db.Execute.%s: %w", statement[0:le], err)
+	}
+
+	return nil
+}
+
+// ExecuteF #exit on error
+func (v *DB) ExecuteF(statement string) {
+	err := v.Execute(statement)
+	if err != nil {
+		fmt.Println("error:", v.ErrMsg(err))
 		v.ExitF(1)
 	}
 }
 
+// AddOp # count(*) statement of an OpExist* key, '%s' for the name parts
+//
+// Deprecated: implement Dialect.Exist instead.
+func (v *DB) AddOp(key string, val string) {
+	obj, ok := opObjects[key]
+	if !ok {
+		return
+	}
+
+	d, ok := v.Dialect.(opDialect)
+	if !ok {
+		d = opDialect{v.Dialect, map[Object]string{}}
+	}
+
+	ss := strings.Split(strings.ReplaceAll(val, "'%s'", "%s"), "%s")
+	for i := 1; i < len(ss); i++ {
+		ss[i] = d.Bind().Placeholder(i) + ss[i]
+	}
+
+	d.ops[obj] = strings.Join(ss, "")
+	v.Dialect = d
+}
+
+func (v *DB) existArgs(obj Object, sName string) (string, []interface{}, error) {
+	sq := v.Dialect.Exist(obj)
+	if sq == "" {
+		return "", nil, ErrNotSupported
+	}
+
+	if ObjectParts(obj) == 2 {
+		elem := strings.Split(sName, ".")
+		if len(elem) != 2 {
+			return "", nil, errors.New("dbx: expected TABLE.NAME, got " + sName)
+		}
+		return sq, []interface{}{elem[0], elem[1]}, nil
+	}
+
+	return sq, []interface{}{sName}, nil
+}
+
+// Exist # object of kind obj
+func (v *DB) Exist(obj Object, sName string) bool {
+	var sq string
+	var args []interface{}
+
+	sq, args, v.Err = v.existArgs(obj, sName)
+	return v.Err == nil && v.QueryI(sq, args...) > 0
+}
+
 // ExistTable #
 func (v *DB) ExistTable(sName string) bool {
-	sq := v.dbOp[OpExistTable]
-	return len(sq) > 9 && v.ExecI(sq, sName) > 0
+	return v.Exist(ObjTable, sName)
 }
 
 // ExistTableCol #
 func (v *DB) ExistTableCol(sName string) bool {
-	sq := v.dbOp[OpExistTableCol]
-	elem := strings.Split(sName, ".")
-	if len(sq) > 9 && len(elem) == 2 {
-		return v.ExecI(sq, elem[0], elem[1]) > 0
-	}
-
-	return false
+	return v.Exist(ObjTableCol, sName)
 }
 
 // ExistIndex #
 func (v *DB) ExistIndex(sName string) bool {
-	sq := v.dbOp[OpExistIdx]
-
-	elem := strings.Split(sName, ".")
-	if len(sq) > 9 && len(elem) == 2 {
-		return v.ExecI(sq, elem[0], elem[1]) > 0
-	}
-
-	return false
+	return v.Exist(ObjIndex, sName)
 }
 
 // ExistProc #
 func (v *DB) ExistProc(sName string) bool {
-	sq := v.dbOp[OpExistProc]
-	return len(sq) > 9 && v.ExecI(sq, sName) > 0
+	return v.Exist(ObjProc, sName)
 }
 
 // ExistFunc #
 func (v *DB) ExistFunc(sName string) bool {
-	sq := v.dbOp[OpExistFunc]
-	return len(sq) > 9 && v.ExecI(sq, sName) > 0
+	return v.Exist(ObjFunc, sName)
 }
 
 // ExistTrigger #
 func (v *DB) ExistTrigger(sName string) bool {
-	sq := v.dbOp[OpExistTrg]
-	elem := strings.Split(sName, ".")
-	if len(sq) > 9 && len(elem) == 2 {
-		return v.ExecI(sq, elem[0], elem[1]) > 0
-	}
-
-	return false
+	return v.Exist(ObjTrigger, sName)
 }
 
-// ExistDom #
+// ExistDomain #
 func (v *DB) ExistDomain(sName string) bool {
-	sq := v.dbOp[OpExistDom]
-	return len(sq) > 9 && v.ExecI(sq, sName) > 0
+	return v.Exist(ObjDomain, sName)
 }
 
-// ExistDom #
+// ExistException #
 func (v *DB) ExistException(sName string) bool {
-	sq := v.dbOp[OpExistExc]
-	return len(sq) > 9 && v.ExecI(sq, sName) > 0
+	return v.Exist(ObjException, sName)
+}
+
+// CallProc # with bind args, using the call pattern of the dialect
+func (v *DB) CallProc(proc string, args ...interface{}) *SQLX {
+	sq := v.Dialect.CallProc(proc, len(args))
+
+	q := NewSQLX(v)
+	q.prepareStmt(sq)
+	q.execSelect(context.Background(), sq, args...)
+	return q
 }
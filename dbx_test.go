@@ -11,14 +11,27 @@ package dbx_test
 // ----------------------------------------------------------------------------------
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/waldurbas/dbx"
 	"github.com/waldurbas/dbx/dbt/fdb"
+	"github.com/waldurbas/dbx/dbt/msd"
 	"github.com/waldurbas/dbx/dbt/myd"
+	"github.com/waldurbas/dbx/dbt/pgd"
+	"github.com/waldurbas/dbx/dbt/sqd"
 	"github.com/waldurbas/dbx/script"
+	"github.com/waldurbas/dbx/xbase"
 
 	"testing"
 )
@@ -58,98 +71,6 @@ func TestFDB(t *testing.T) {
 		return
 	}
 
-	type dbu struct {
-		db       *dbx.DB
-		ExecCmd  func(a int, ix int, cmd string) (bool, error)
-		SaveVers func(v int) error
-	}
-
-	xdb := &dbu{db: db,
-		ExecCmd: func(cmdID int, idx int, cmd string) (bool, error) {
-			fmt.Printf("\ncmdID: %d, idx: %d, cmd: [%s]\n", cmdID, idx, cmd)
-
-			switch cmdID {
-			case script.TkExit:
-				fmt.Println("->EXIT...")
-				return true, nil
-
-			case script.TkEcho:
-				// 0123456
-				// $echo ..
-				fmt.Println("->echo:", strings.TrimSpace(cmd[6:]))
-				return false, nil
-
-			case script.TkSet:
-				s := strings.TrimSpace(cmd[5:])
-				if len(s) > 0 && s[:1] == "@" {
-					fmt.Println("->set:", s[1:])
-					return false, nil
-				}
-			}
-
-			getCmd := func(cmdID int, cmd string) string {
-				switch cmdID {
-				case script.TkAdd:
-					ss := strings.Fields(cmd)
-					le := len(ss)
-					if le > 3 {
-						if ss[1] == "field" {
-							tbl := strings.Split(ss[2], ".")
-							if len(tbl) == 2 {
-								sf := tbl[1]
-								for _, xf := range ss[3:] {
-									sf = sf + " " + xf
-								}
-
-								return fmt.Sprintf("alter table %s add %s", tbl[0], sf)
-							}
-						}
-					}
-				}
-				return cmd
-			}
-
-			sq := getCmd(cmdID, cmd)
-			if sq == "" {
-				return false, nil
-			}
-
-			fmt.Println("->sql :", sq)
-
-			return false, nil
-		},
-		SaveVers: func(v int) error {
-			return nil
-		},
-	}
-
-	dbs := script.NewScript()
-	dbs.ExecCmd = xdb.ExecCmd
-	dbs.ExistFunc = db.ExistFunc
-	dbs.ExistIndex = db.ExistIndex
-	dbs.ExistProc = db.ExistProc
-	dbs.ExistTrigger = db.ExistTrigger
-	dbs.ExistTable = db.ExistTable
-	dbs.ExistTableCol = db.ExistTableCol
-	dbs.ExistDomain = db.ExistDomain
-	dbs.ExistException = db.ExistException
-	dbs.SaveVers = xdb.SaveVers
-	/*
-		// select for Version
-		sver := os.Getenv("FDB_VER")
-		if sver == "" {
-			t.Errorf("\n\nenv.variable FDB_VER not defined")
-			return
-		}
-		q := db.ExecQ(sver)
-		if q.Fetch() {
-			dbs.Vinfo.Dbu = q.AsInteger(0)
-			dbs.Vinfo.App = q.AsString(1)
-			dbs.Vinfo.Chg = q.AsString(2)
-		}
-		q.Close()
-	*/
-
 	// scriptname
 	scr := os.Getenv("FDB_SCR")
 	if scr == "" {
@@ -157,20 +78,15 @@ func TestFDB(t *testing.T) {
 		return
 	}
 
-	px := script.NewParser()
-	err := px.LoadFile(scr)
-	if err != nil {
-		fmt.Printf("LoadScript, err: %v", err)
-		return
-	}
-
-	a, err := dbs.Execute(px)
+	// dry run, nothing is changed
+	p, err := script.NewRunner(db).PlanFile(scr)
 	if err != nil {
-		fmt.Printf("Execute.Script, a:%d, err: %v", a, err)
+		fmt.Printf("Plan.Script, err: %v", err)
 		return
 	}
 
-	fmt.Println("Execute.Script OK..")
+	fmt.Print(p)
+	fmt.Println("Plan.Script OK..")
 }
 
 func TestMYD(t *testing.T) {
@@ -199,36 +115,6 @@ func TestMYD(t *testing.T) {
 		return
 	}
 
-	type dbu struct {
-		db       *dbx.DB
-		ExecCmd  func(a int, ix int, cmd string) (bool, error)
-		SaveVers func(v int) error
-	}
-
-	xdb := &dbu{db: db,
-		ExecCmd: func(a int, ix int, cmd string) (bool, error) {
-			if cmd == "$exit" {
-				return true, nil
-			}
-
-			_, err := db.Exec(cmd)
-
-			return false, err
-		},
-		SaveVers: func(v int) error {
-			return nil
-		},
-	}
-
-	dbs := script.NewScript()
-	dbs.ExecCmd = xdb.ExecCmd
-	dbs.ExistFunc = db.ExistFunc
-	dbs.ExistIndex = db.ExistIndex
-	dbs.ExistProc = db.ExistProc
-	dbs.ExistTable = db.ExistTable
-	dbs.ExistTableCol = db.ExistTableCol
-	dbs.SaveVers = xdb.SaveVers
-
 	sql := os.Getenv("MYD_SQL")
 	if len(sql) > 0 {
 		if strings.Index(sql, "call ") == 0 {
@@ -243,6 +129,8 @@ func TestMYD(t *testing.T) {
 		}
 	}
 
+	r := script.NewRunner(db)
+
 	sver := os.Getenv("MYD_VER")
 	if sver == "" {
 		t.Errorf("env.variable MYD_VER not defined")
@@ -250,9 +138,9 @@ func TestMYD(t *testing.T) {
 	}
 	q := db.ExecQ(sver)
 	if q.Fetch() {
-		dbs.Vinfo.Dbu = q.AsInteger(0)
-		dbs.Vinfo.App = q.AsString(1)
-		dbs.Vinfo.Chg = q.AsString(2)
+		r.Vinfo.Dbu = q.AsInteger(0)
+		r.Vinfo.App = q.AsString(1)
+		r.Vinfo.Chg = q.AsString(2)
 	}
 	q.Close()
 
@@ -262,40 +150,1498 @@ func TestMYD(t *testing.T) {
 		return
 	}
 
-	px := script.NewParser()
-	err := px.LoadFile(scr)
+	// dry run, neither the schema nor a ledger is written
+	p, err := r.PlanFile(scr)
 	if err != nil {
-		fmt.Printf("LoadScript, err: %v", err)
+		fmt.Printf("Plan.Script, err: %v", err)
 		return
 	}
 
-	a, err := dbs.Execute(px)
-	if err != nil {
-		fmt.Printf("Execute.Script, a:%d, err: %v", a, err)
+	fmt.Print(p)
+}
+
+func TestPGD(t *testing.T) {
+	conStr := os.Getenv("PGD_CON")
+	if conStr == "" {
+		fmt.Println("\nenv.variable PGD_CON not defined")
+		return
+	}
+
+	db, err := pgd.Open(conStr)
+	if err != nil || !db.Connect() {
+		t.Errorf("pgd.Connect fail, err: %v %v", err, db.Err)
 		return
 	}
+	defer db.Close()
+
+	if db.ExistTable("DBX_NOT_EXISTING") || db.Err != nil {
+		t.Errorf("Exist-Table fail, err: %v", db.Err)
+	}
+
+	q := db.CallProc("generate_series", 1, 3)
+	n := 0
+	for q.Fetch() {
+		n++
+	}
+	q.Close()
+
+	if q.Err != nil || n != 3 {
+		t.Errorf("CallProc fail, n: %d, err: %v", n, q.Err)
+	}
 }
 
-func TestFunc(t *testing.T) {
+const sqdScript = `# dbx test script
+$ine create table A (
+  ID integer not null primary key,
+  NAME varchar(20),
+  CREATED date
+)&&
+$ine add field A.TXT varchar(30)&&
+$ine create index IX_A_NAME on A (NAME)&&
+insert into A (ID, NAME, CREATED) values (1, 'eins', '2023-04-01')&&
+insert into A (ID, NAME, TXT) values (2, 'zwei', 'x;y')&&
+$lastdbu = 1.01
+`
+
+func sqdOpen(t *testing.T) *dbx.DB {
+	db, err := sqd.Open(":memory:")
+	if err != nil || !db.Connect() {
+		t.Fatalf("sqd.Open fail, err: %v %v", err, db.Err)
+	}
+
+	return db
+}
+
+// sqdFile # src as dbu.txt in a temp dir
+func sqdFile(t *testing.T, src string) string {
+	fn := filepath.Join(t.TempDir(), "dbu.txt")
+	if err := os.WriteFile(fn, []byte(src), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	return fn
+}
+
+// sqdRun # src with a new Runner, opts set its options before the run
+func sqdRun(t *testing.T, db *dbx.DB, src string, opts ...func(r *script.Runner)) (*script.Runner, error) {
+	r := script.NewRunner(db)
+	for _, o := range opts {
+		o(r)
+	}
+
+	_, err := r.Run(sqdFile(t, src))
+	return r, err
+}
+
+// bindAs # sqlite with the placeholders of another driver
+type bindAs struct {
+	dbx.Dialect
+	bt dbx.BindType
+}
+
+func (d bindAs) Bind() dbx.BindType { return d.bt }
+
+func TestBind(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	if err := db.Execute("create table B (ID integer, N varchar(10))"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if err := db.Execute("insert into B (ID,N) values (:id,:name)", sql.Named("id", 1), dbx.Params{"name": "x"}); err != nil {
+		t.Fatalf("Execute named: %v", err)
+	}
+
 	var ar = []struct {
-		vs string
-		vi string
+		sq  string
+		out string
 	}{
-		{"-20", "-20"},
-		{"  0- 20  ", "-020"},
-		{" + 25  ", "25"},
-		{"-  40  ", "-40"},
-		{"  30-  ", "-30"},
+		{"select N from B where ID=:id and N<>':id' and ID=:Id", "x"},
+		{"select N||':x' from B -- :id\nwhere ID=:id", "x:x"},
+		{"/* :id */ select N from B where N=:name and ID=:id", "x"},
 	}
 
-	f := dbx.SqxField{Typ: "INT"}
+	// @p1 of BindAt is a named parameter in sqlite
+	base := db.Dialect
+	for _, bt := range []dbx.BindType{dbx.BindQuestion, dbx.BindDollar} {
+		db.Dialect = bindAs{base, bt}
+		for _, a := range ar {
+			s := db.QueryS(a.sq, sql.Named("id", 1), dbx.Params{"name": "x"})
+			if db.Err != nil || s != a.out {
+				t.Errorf("QueryS(%d,'%s'): soll '%s', ist '%s' %v", bt, a.sq, a.out, s, db.Err)
+			}
+		}
+	}
+	db.Dialect = base
+
+	if err := db.Execute("update B set N=:a where ID=?", 1, sql.Named("a", "y")); !errors.Is(err, dbx.ErrMixedParams) {
+		t.Errorf("Execute mixed: soll ErrMixedParams, ist %v", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	if err := db.Execute("create table C (ID integer)"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	var ar = []struct {
+		name string
+		ctx  context.Context
+		is   func(error) bool
+	}{
+		{"canceled", canceled, dbx.IsCanceled},
+		{"deadline", expired, dbx.IsTimeout},
+	}
 
 	for _, a := range ar {
-		f.Value = []byte(a.vs)
-		s := f.CleanedString(false)
+		if err := db.ExecuteContext(a.ctx, "insert into C (ID) values (1)"); !a.is(err) {
+			t.Errorf("ExecuteContext %s: ist %v", a.name, err)
+		}
 
-		if a.vi != s {
-			t.Errorf("CleanString('%s'): soll '%s', ist '%s'", a.vs, a.vi, s)
+		if _, err := db.ScanIContext(a.ctx, "select count(*) from C"); !a.is(err) {
+			t.Errorf("ScanIContext %s: ist %v", a.name, err)
+		}
+
+		if _, err := db.ExecuteQContext(a.ctx, "select ID from C"); !a.is(err) {
+			t.Errorf("ExecuteQContext %s: ist %v", a.name, err)
+		}
+
+		q := db.CreateSqlx()
+		if q.ExecContext(a.ctx, "select ID from C") || !a.is(q.Err) {
+			t.Errorf("SQLX.ExecContext %s: ist %v", a.name, q.Err)
+		}
+	}
+
+	if n, err := db.ScanIContext(context.Background(), "select count(*) from C"); err != nil || n != 0 {
+		t.Errorf("ScanIContext: soll 0, ist %d %v", n, err)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	if err := db.Execute("create table T (ID integer)"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	count := func() int {
+		n, err := db.ScanI("select count(*) from T")
+		if err != nil {
+			t.Fatalf("ScanI: %v", err)
+		}
+		return n
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	tx.Execute("insert into T (ID) values (:id)", sql.Named("id", 1))
+	if n, err := tx.ScanI("select count(*) from T"); err != nil || n != 1 {
+		t.Errorf("Tx.ScanI: soll 1, ist %d %v", n, err)
+	}
+	if err = tx.Rollback(); err != nil || count() != 0 {
+		t.Errorf("Rollback: soll 0, ist %d %v", count(), err)
+	}
+
+	if tx, err = db.BeginTx(context.Background(), nil); err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	tx.Execute("insert into T (ID) values (1)")
+	if err = tx.Commit(); err != nil || count() != 1 {
+		t.Errorf("Commit: soll 1, ist %d %v", count(), err)
+	}
+
+	errFail := errors.New("fail")
+	err = db.WithTx(func(tx *dbx.Tx) error {
+		if err := tx.Execute("insert into T (ID) values (2)"); err != nil {
+			return err
+		}
+		q := tx.CreateSqlx()
+		if !q.ExecArgs("select ID from T") {
+			return q.Err
+		}
+		q.Close()
+		return errFail
+	})
+	if err != errFail || count() != 1 {
+		t.Errorf("WithTx error: soll 1, ist %d %v", count(), err)
+	}
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("WithTx panic: soll boom, ist %v", p)
+			}
+		}()
+
+		db.WithTx(func(tx *dbx.Tx) error {
+			tx.Execute("insert into T (ID) values (3)")
+			panic("boom")
+		})
+	}()
+	if n := count(); n != 1 {
+		t.Errorf("WithTx panic: soll 1, ist %d", n)
+	}
+
+	if err = db.WithTx(func(tx *dbx.Tx) error {
+		return tx.Execute("insert into T (ID) values (4)")
+	}); err != nil || count() != 2 {
+		t.Errorf("WithTx: soll 2, ist %d %v", count(), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	if err = db.WithTxContext(ctx, nil, func(tx *dbx.Tx) error {
+		called = true
+		return nil
+	}); !dbx.IsCanceled(err) || called {
+		t.Errorf("WithTxContext canceled: ist %v, fn called %v", err, called)
+	}
+}
+
+func TestAddOp(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.ExecuteF("create table A (ID integer)")
+	if !db.ExistTable("A") {
+		t.Fatalf("ExistTable: A must exist")
+	}
+
+	// statements of the former AddOp replace those of the dialect
+	db.AddOp(dbx.OpExistTable, "select count(*) from sqlite_master where type='view' and name='%s'")
+	if soll := "select count(*) from sqlite_master where type='view' and name=?"; db.Dialect.Exist(dbx.ObjTable) != soll {
+		t.Errorf("AddOp: soll %s, ist %s", soll, db.Dialect.Exist(dbx.ObjTable))
+	}
+	if db.ExistTable("A") || !db.ExistTableCol("A.ID") {
+		t.Errorf("AddOp: A is no view, A.ID exists")
+	}
+}
+
+func TestSQD(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	dbs, err := sqdRun(t, db, sqdScript)
+	if err != nil {
+		t.Fatalf("Execute.Script: %v", err)
+	}
+
+	if dbs.Vinfo.Dbu != 101 {
+		t.Errorf("Vinfo.Dbu: soll 101, ist %d", dbs.Vinfo.Dbu)
+	}
+
+	if !db.ExistTableCol("a.txt") || !db.ExistIndex("A.IX_A_NAME") || db.ExistTrigger("A.TR_X") {
+		t.Errorf("Exist fail, err: %v", db.Err)
+	}
+
+	if db.ExistProc("P"); db.Err != dbx.ErrNotSupported {
+		t.Errorf("ExistProc: expected ErrNotSupported, got %v", db.Err)
+	}
+
+	// second run finds everything in place
+	if _, err = sqdRun(t, db, sqdScript[:strings.Index(sqdScript, "insert")]); err != nil {
+		t.Errorf("Execute.Script again: %v", err)
+	}
+
+	q := db.QueryQ("select ID,NAME,CREATED from A where ID=:id", sql.Named("id", 1))
+	if !q.Fetch() || q.AsString(1) != "eins" || q.Fields[2].Typ != "DATE" || q.AsString(2) != "2023-04-01" {
+		t.Errorf("QueryQ fail, err: %v", q.Err)
+	}
+	q.Close()
+
+	var b bytes.Buffer
+	q = db.QueryQ("select ID,NAME,TXT from A order by ID")
+	if err = q.PrintTo(&b, "csv"); err != nil {
+		t.Errorf("PrintTo: %v", err)
+	}
+	q.Close()
+
+	if b.String() != "ID;NAME;TXT\n1;eins;\n2;zwei;\"x;y\"\n" {
+		t.Errorf("PrintTo: [%s]", b.String())
+	}
+}
+
+func TestEcv(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	src := sqdScript[:strings.Index(sqdScript, "insert")] + `$ecv_start
+@A,ID[int],NAME[char_20],CREATED[date],TXT[str]
+1^eins^2023-04-01^a\^b
+2^NULL^^\N
+$ecv_stop
+`
+	escaped := func(r *script.Runner) { r.EcvOptions = &dbx.EcvOptions{} }
+	if _, err := sqdRun(t, db, src, escaped); err != nil {
+		t.Fatalf("Execute.Script: %v", err)
+	}
+
+	ecv := "@A,ID[int],NAME[char_20]\n2^zwei\n3^drei\n"
+	n, err := db.ImportEcv(strings.NewReader(ecv), &dbx.EcvOptions{Keys: map[string][]string{"A": {"ID"}}, Batch: 1})
+	if err != nil || n != 2 {
+		t.Fatalf("ImportEcv: %d %v", n, err)
+	}
+
+	var b bytes.Buffer
+	q := db.QueryQ("select ID,NAME,CREATED,TXT from A order by ID")
+	q.PrintTo(&b, "csv")
+	q.Close()
+
+	if b.String() != "ID;NAME;CREATED;TXT\n1;eins;2023-04-01;a^b\n2;zwei;;\n3;drei;;\n" {
+		t.Errorf("ImportEcv: [%s]", b.String())
+	}
+
+	if _, err = db.ImportEcv(strings.NewReader("@A,ID[int]\nx\n"), nil); err == nil {
+		t.Errorf("ImportEcv: expected error for bad int")
+	}
+
+	// columns unknown to the table only with SkipUnknown
+	ecv = "@A,ID[int],OLD[int]\n5^7\n"
+	if _, err = db.ImportEcv(strings.NewReader(ecv), nil); err == nil || !strings.Contains(err.Error(), "OLD") {
+		t.Errorf("ImportEcv: expected error for unknown column OLD, got %v", err)
+	}
+
+	if n, err = db.ImportEcv(strings.NewReader(ecv), &dbx.EcvOptions{SkipUnknown: true}); err != nil || n != 1 {
+		t.Errorf("ImportEcv SkipUnknown: %d %v", n, err)
+	}
+	db.ExecuteF("delete from A where ID=5")
+
+	// ShowLineAsEcv is read back as it was
+	db.ExecuteF(`insert into A (ID,NAME,TXT) values (4, 'C:\temp', NULL)`)
+	q = db.QueryQ("select ID,NAME,TXT from A where ID=4")
+	q.QName = "A"
+	title := q.ShowLineAsEcv(true)
+	q.Fetch()
+	line := q.ShowLineAsEcv(false)
+	q.Close()
+
+	h, err := dbx.ParseEcvHeader(title)
+	if err != nil {
+		t.Fatalf("ParseEcvHeader: %v", err)
+	}
+
+	vv, err := h.Values(strings.TrimSuffix(line, "\n"))
+	if err != nil || len(vv) != 3 || vv[1] != `C:\temp` || vv[2] != nil {
+		t.Errorf("ShowLineAsEcv: [%s] %q %v", line, vv, err)
+	}
+
+	// lines of the former ShowLineAsEcv without escapes, raw in scripts by default
+	src = "$ecv_start\n@A,ID[int],NAME[char_20]\n6^C:\\temp\n$ecv_stop\n"
+	if _, err = sqdRun(t, db, src); err != nil {
+		t.Fatalf("Execute.Raw: %v", err)
+	}
+	if s := db.QueryS("select NAME from A where ID=6"); s != `C:\temp` {
+		t.Errorf("Execute.Raw: soll [C:\\temp], ist [%s]", s)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	src := `$ine create table B (ID integer not null primary key, OLD varchar(10), NOTE varchar(10))&&
+$ine create index IX_B_NOTE on B (NOTE)&&
+$drop index B.IX_B_NOTE&&
+$drop field B.OLD&&
+$drop trigger B.TR_NONE&&
+recreate table C (ID integer)&&
+$echo done
+$lastdbu = 1.02
+`
+	fn := sqdFile(t, src)
+
+	for i := 0; i < 2; i++ {
+		var b bytes.Buffer
+		r := script.NewRunner(db)
+		r.Out = &b
+
+		if _, err := r.Run(fn); err != nil {
+			t.Fatalf("Run #%d: %v", i, err)
+		}
+
+		if r.Vinfo.Dbu != 102 || b.String() != "done\n" {
+			t.Errorf("Run #%d: Dbu %d, echo [%s]", i, r.Vinfo.Dbu, b.String())
+		}
+	}
+
+	if db.ExistTableCol("B.OLD") || db.ExistIndex("B.IX_B_NOTE") || !db.ExistTableCol("B.NOTE") || !db.ExistTable("C") {
+		t.Errorf("Runner: schema not as expected, err: %v", db.Err)
+	}
+
+	if n := db.QueryI("select count(*) from " + script.VersTable + " where VERSION='1.02' and DBU=102"); n != 1 {
+		t.Errorf("Runner: %d version rows, err: %v", n, db.Err)
+	}
+
+	sqs, err := script.NewRunner(db).Translate("add field A.X varchar(10) default ''")
+	if err != nil || len(sqs) != 1 || sqs[0] != "alter table A add X varchar(10) default ''" {
+		t.Errorf("Translate: %v %v", sqs, err)
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	// without Blocks, $dbu_start and $dbu_end reach ExecCmd as before
+	cmds := []string{}
+	dbs := script.NewScript()
+	dbs.ExecCmd = func(a int, ix int, cmd string) (bool, error) {
+		cmds = append(cmds, cmd)
+		return false, nil
+	}
+
+	px, err := dbs.LoadFile(sqdFile(t, "$dbu_start 1.01\ncreate table A (ID integer)&&\n$dbu_end\n"))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if _, err = dbs.Execute(px); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	soll := "$dbu_start 1.01|create table A (ID integer)|$dbu_end"
+	if ist := strings.Join(cmds, "|"); ist != soll {
+		t.Errorf("ExecCmd: soll [%s], ist [%s]", soll, ist)
+	}
+	if !dbs.Vinfo.Vers.IsZero() {
+		t.Errorf("Vers: soll 0, ist %s", dbs.Vinfo.Vers)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	// table of an older runner
+	db.ExecuteF("create table " + script.VersTable + " (VERSION varchar(20) not null, DBU integer, APPLIED varchar(19))")
+	db.ExecuteF("insert into " + script.VersTable + " values ('1.01', 101, '2023-05-01 10:00:00')")
+
+	src := `$dbu_version < 2.00
+$dbu_start 1.01
+no sql at all&&
+$dbu_end
+$dbu_start 1.02
+create table L (ID integer)&&
+$dbu_end
+$dbu_start 1.120
+create table M (ID integer)&&
+$dbu_end
+`
+	for i := 0; i < 2; i++ {
+		r, err := sqdRun(t, db, src)
+		if err != nil {
+			t.Fatalf("Run #%d: %v", i, err)
+		}
+
+		if r.Vinfo.Vers != (script.Version{Major: 1, Minor: 120}) {
+			t.Errorf("Run #%d: Vers %v", i, r.Vinfo.Vers)
+		}
+
+		steps, err := r.Versions()
+		if err != nil || len(steps) != 3 {
+			t.Fatalf("Versions: %d %v", len(steps), err)
+		}
+
+		st := steps[2]
+		if st.Version.String() != "1.120" || st.Script != "dbu.txt" || st.Host == "" || st.Duration() < 0 || steps[0].Version.String() != "1.01" {
+			t.Errorf("Versions: %+v", st)
+		}
+	}
+
+	if !db.ExistTable("M") {
+		t.Errorf("Ledger: table M missing")
+	}
+
+	// DBU without the collision of 1.120 and 2.20
+	if n := db.QueryI("select count(*) from "+script.VersTable+" where DBU is null and VERSION=?", "1.120"); n != 1 {
+		t.Errorf("Ledger: DBU of 1.120 must be NULL")
+	}
+
+	var saved []int
+	legacy := func(r *script.Runner) {
+		r.Vinfo.Dbu = 101
+		r.SaveStep = nil
+		r.SaveVers = func(v int) error {
+			saved = append(saved, v)
+			return nil
 		}
 	}
+	db3 := sqdOpen(t)
+	defer db3.Close()
+	if _, err := sqdRun(t, db3, src, legacy); !errors.Is(err, script.ErrDbuRange) || fmt.Sprint(saved) != "[102]" {
+		t.Errorf("SaveVers: expected ErrDbuRange after [102], got %v %v", saved, err)
+	}
+
+	if _, err := sqdRun(t, db, "$dbu_end\n"); err == nil {
+		t.Errorf("Ledger: expected error for $dbu_end without $dbu_start")
+	}
+
+	if _, err := sqdRun(t, db, "$dbu_version = 1.119\n"); err == nil {
+		t.Errorf("Ledger: expected bad version")
+	}
+
+	// a database without ledger starts at the version of the caller
+	db2 := sqdOpen(t)
+	defer db2.Close()
+
+	r, err := sqdRun(t, db2, src, func(r *script.Runner) { r.Vinfo.Dbu = 101 })
+	if err != nil || !db2.ExistTable("L") || r.Vinfo.Vers.String() != "1.120" {
+		t.Errorf("Run preset: %v %v", r.Vinfo.Vers, err)
+	}
+
+	if steps, _ := r.Versions(); len(steps) != 2 || steps[0].Version.String() != "1.02" {
+		t.Errorf("Versions preset: %+v", steps)
+	}
+
+	if v, err := script.ParseVersion("2.5"); err != nil || v.String() != "2.05" || !v.Follows(script.Version{Major: 2, Minor: 4}) {
+		t.Errorf("ParseVersion: %v %v", v, err)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	src := `$dbu_start 1.01
+create table X (ID integer, NAME varchar(10) default 'a  b')&&
+$dbu_end
+$dbu_start 1.02
+$ine add field X.N integer&&
+$dbu_end
+`
+	run := func(src string, mode script.DriftMode) (*script.Runner, string, error) {
+		var b bytes.Buffer
+		r, err := sqdRun(t, db, src, func(r *script.Runner) {
+			r.Out = &b
+			r.Drift = mode
+		})
+		return r, b.String(), err
+	}
+
+	if _, _, err := run(src, script.DriftRefuse); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// layout and comments do not count
+	same := strings.Replace(src, "create table X (ID integer,", "# comment\ncreate  table X -- x\n  (ID /* id */ integer,", 1)
+	if r, _, err := run(same, script.DriftRefuse); err != nil || len(r.Drifts) != 0 {
+		t.Fatalf("Run same: %v %v", err, r.Drifts)
+	}
+
+	changed := strings.Replace(src, "'a  b'", "'a b'", 1)
+	if _, _, err := run(changed, script.DriftRefuse); !errors.Is(err, script.ErrDrift) {
+		t.Errorf("Run changed: expected ErrDrift, got %v", err)
+	}
+
+	r, out, err := run(changed, script.DriftWarn)
+	if err != nil || len(r.Drifts) != 1 || r.Drifts[0].Version.String() != "1.01" || !strings.HasPrefix(out, "warning:") {
+		t.Errorf("Run warn: %v %v [%s]", err, r.Drifts, out)
+	}
+
+	if _, _, err = run(changed, script.DriftRepair); err != nil {
+		t.Errorf("Run repair: %v", err)
+	}
+
+	if r, _, err = run(changed, script.DriftRefuse); err != nil || len(r.Drifts) != 0 {
+		t.Errorf("Run repaired: %v %v", err, r.Drifts)
+	}
+
+	// recorded without checksum, the script is taken as it is
+	db.ExecuteF("insert into " + script.VersTable + " (VERSION,DBU) values ('2.00',200)")
+	if r, _, err = run(changed+"$dbu_start 2.00\nbad sql&&\n$dbu_end\n", script.DriftRefuse); err != nil {
+		t.Fatalf("Run adopt: %v", err)
+	}
+
+	steps, _ := r.Versions()
+	if len(steps) != 3 || len(steps[2].Checksum) != 64 {
+		t.Errorf("Versions: %+v", steps)
+	}
+}
+
+// noTxDDL # sqlite as if the ddl would commit implicitly
+type noTxDDL struct{ dbx.Dialect }
+
+func (noTxDDL) TxDDL() bool { return false }
+
+type ddlAtCommit struct{ dbx.Dialect }
+
+func (ddlAtCommit) DDLAtCommit() bool { return true }
+
+func TestTx(t *testing.T) {
+	src := `$dbu_start 1.01
+create table A (ID integer)&&
+$dbu_end
+$dbu_start 1.02
+create table B (ID integer)&&
+create table C (ID integer)&&
+insert into Z values (1)&&
+$dbu_end
+`
+	run := func(db *dbx.DB, src string, scope script.TxScope) (*script.Runner, error) {
+		return sqdRun(t, db, src, func(r *script.Runner) { r.TxScope = scope })
+	}
+
+	// a failing block is rolled back, the blocks before stay
+	db := sqdOpen(t)
+	r, err := run(db, src, script.TxStep)
+	if err == nil {
+		t.Fatalf("Run step: expected error")
+	}
+	if !db.ExistTable("A") || db.ExistTable("B") || db.ExistTable("C") {
+		t.Errorf("Run step: A must exist, B and C not")
+	}
+	if v, _ := r.LoadVers(); v.String() != "1.01" {
+		t.Errorf("Run step: vers %s", v)
+	}
+	db.Close()
+
+	// nothing stays of the whole script
+	db = sqdOpen(t)
+	if r, err = run(db, src, script.TxScript); err == nil {
+		t.Fatalf("Run script: expected error")
+	}
+	if db.ExistTable("A") || db.ExistTable("B") {
+		t.Errorf("Run script: A and B must not exist")
+	}
+	if v, _ := r.LoadVers(); !v.IsZero() {
+		t.Errorf("Run script: vers %s", v)
+	}
+	db.Close()
+
+	// without transactional ddl the block resumes after the commands done
+	db = sqdOpen(t)
+	defer db.Close()
+	db.Dialect = noTxDDL{db.Dialect}
+
+	if r, err = run(db, src, script.TxStep); err == nil {
+		t.Fatalf("Run checkpoint: expected error")
+	}
+
+	cps, err := r.Checkpoints()
+	if err != nil || len(cps) != 1 || cps[0].Version.String() != "1.02" || cps[0].Done != 2 {
+		t.Fatalf("Checkpoints: %v %+v", err, cps)
+	}
+
+	changed := strings.Replace(src, "create table C (ID integer)", "create table C (ID integer, N integer)", 1)
+	if _, err = run(db, changed, script.TxStep); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("Run changed: %v", err)
+	}
+
+	fixed := strings.Replace(src, "insert into Z values (1)", "insert into C values (1)", 1)
+	if r, err = run(db, fixed, script.TxStep); err != nil {
+		t.Fatalf("Run resume: %v", err)
+	}
+	if v, _ := r.LoadVers(); v.String() != "1.02" {
+		t.Errorf("Run resume: vers %s", v)
+	}
+	if cps, err = r.Checkpoints(); err != nil || len(cps) != 0 {
+		t.Errorf("Checkpoints after resume: %v %+v", err, cps)
+	}
+
+	// ddl applied at commit is committed with its checkpoint before the next statement
+	db2 := sqdOpen(t)
+	defer db2.Close()
+	db2.Dialect = ddlAtCommit{db2.Dialect}
+
+	src = `$dbu_start 1.01
+create table B (ID integer)&&
+insert into B values (1)&&
+insert into Z values (1)&&
+$dbu_end
+`
+	if r, err = run(db2, src, script.TxStep); err == nil {
+		t.Fatalf("Run ddl at commit: expected error")
+	}
+	if !db2.ExistTable("B") || db2.QueryI("select count(*) from B") != 0 {
+		t.Errorf("Run ddl at commit: B must exist without rows")
+	}
+	if cps, err = r.Checkpoints(); err != nil || len(cps) != 1 || cps[0].Done != 1 {
+		t.Fatalf("Checkpoints ddl at commit: %v %+v", err, cps)
+	}
+
+	fixed = strings.Replace(src, "insert into Z values (1)", "insert into B values (2)", 1)
+	if r, err = run(db2, fixed, script.TxStep); err != nil {
+		t.Fatalf("Run ddl at commit resume: %v", err)
+	}
+	if n := db2.QueryI("select count(*) from B"); n != 2 {
+		t.Errorf("Run ddl at commit resume: soll 2, ist %d", n)
+	}
+	if v, _ := r.LoadVers(); v.String() != "1.01" {
+		t.Errorf("Run ddl at commit resume: vers %s", v)
+	}
+	if cps, err = r.Checkpoints(); err != nil || len(cps) != 0 {
+		t.Errorf("Checkpoints ddl at commit resume: %v %+v", err, cps)
+	}
+
+	// blocks committed with their ddl cannot be rolled back with the script
+	db3 := sqdOpen(t)
+	defer db3.Close()
+	db3.Dialect = ddlAtCommit{db3.Dialect}
+
+	if _, err = run(db3, src, script.TxScript); !errors.Is(err, script.ErrTxScript) {
+		t.Errorf("Run script ddl at commit: soll ErrTxScript, ist %v", err)
+	}
+	if db3.ExistTable("B") || db3.ExistTable(script.VersTable) || db3.ExistTable(script.CheckTable) {
+		t.Errorf("Run script ddl at commit: nothing may be left")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	// nothing is created, not even the ledger
+	r := script.NewRunner(db)
+	p, err := r.PlanFile(sqdFile(t, sqdScript))
+	if err != nil || p.Vers.String() != "1.01" || len(p.Statements()) != 5 || db.ExistTable("A") || db.ExistTable(script.VersTable) {
+		t.Fatalf("PlanFile: %v %v", err, p)
+	}
+
+	if _, err = sqdRun(t, db, sqdScript); err != nil {
+		t.Fatalf("Execute.Script: %v", err)
+	}
+
+	src := `$dbu_start 1.01
+create table Z (ID integer)&&
+$dbu_end
+$dbu_start 1.02
+$ine create table A (ID integer)&&
+$ine add field A.NOTE varchar(30)&&
+$if exist table NOPE
+drop table NOPE&&
+$fi
+$ecv_start
+@A,ID[int]
+9
+$ecv_stop
+$drop index A.IX_NONE&&
+$dbu_end
+$exit
+create table NEVER (ID integer)&&
+`
+	r = script.NewRunner(db)
+	if p, err = r.PlanFile(sqdFile(t, src)); err != nil {
+		t.Fatalf("PlanFile: %v", err)
+	}
+
+	v1, v2 := script.Version{Major: 1, Minor: 1}, script.Version{Major: 1, Minor: 2}
+	soll := []script.PlanEntry{
+		{Kind: script.PlanSkip, Version: v1, SQL: "$dbu_start 1.01", Reason: "applied, database at 1.01"},
+		{Kind: script.PlanSkip, Version: v2, SQL: "create table A (ID integer)", Reason: "A exists"},
+		{Kind: script.PlanExec, Version: v2, SQL: "alter table A add NOTE varchar(30)"},
+		{Kind: script.PlanSkip, Version: v2, SQL: "drop table NOPE", Reason: "$if exist table NOPE is false"},
+		{Kind: script.PlanEcv, Version: v2, SQL: "@A,ID[int]", Reason: "1 lines"},
+		{Kind: script.PlanSkip, Version: v2, SQL: "$drop index A.IX_NONE", Reason: "does not exist"},
+		{Kind: script.PlanStep, Version: v2},
+		{Kind: script.PlanExit, SQL: "$exit"},
+	}
+
+	if len(p.Entries) != len(soll) {
+		t.Fatalf("Plan: %d entries\n%s", len(p.Entries), p)
+	}
+
+	for i := range soll {
+		if p.Entries[i] != soll[i] {
+			t.Errorf("Plan #%d: soll %+v, ist %+v", i, soll[i], p.Entries[i])
+		}
+	}
+
+	if !strings.Contains(p.String(), "-- dbu 1.02\n-- skip create table A (ID integer): A exists\nalter table A add NOTE varchar(30);\n") || !strings.HasSuffix(p.String(), "-- dbu after the script 1.02\n") {
+		t.Errorf("Plan.String: [%s]", p)
+	}
+
+	if db.ExistTableCol("A.NOTE") || db.ExistTable("NEVER") || r.Vinfo.Dbu != 0 {
+		t.Errorf("Plan changed the database, Dbu %d", r.Vinfo.Dbu)
+	}
+
+	if n := db.QueryI("select count(*) from A"); n != 2 {
+		t.Errorf("Plan imported ecv, %d rows", n)
+	}
+}
+
+func TestExportEcv(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table B (ID integer, AMT decimal(10,2), X double, DATA blob, TXT varchar(30))")
+	db.Execute("insert into B values (1, 12.5, 0.25, x'00ff', '#a^b' || char(10) || 'c\\')")
+	db.Execute("insert into B values (2, null, null, null, 'x&&')")
+	db.Execute("insert into B values (3, -1, 1e3, x'41', '  y ')")
+	db.Execute("insert into B values (4, 0, 0, null, '$ecv_stop')")
+
+	var b bytes.Buffer
+	if err := db.ExportEcv(&b, "B"); err != nil {
+		t.Fatalf("ExportEcv: %v", err)
+	}
+
+	ecv := b.String()
+	if !strings.HasPrefix(ecv, "@B,ID[bigint],AMT[numeric],X[double],DATA[blob],TXT[str]\n1^12.5^0.25^00ff^#a\\^b\\nc\\\\\n2^\\N^\\N^\\N^x&\\&\n3^-1^1000^41^  y\\s\n4^0^0^\\N^$ecv_stop\n") {
+		t.Errorf("ExportEcv: [%s]", ecv)
+	}
+
+	b.Reset()
+	db.ExportEcvQuery(&b, "C", "select TXT from B where ID=4")
+	if b.String() != "@C,TXT[str]\n\\$ecv_stop\n" {
+		t.Errorf("ExportEcvQuery: [%s]", b.String())
+	}
+	ecv += b.String()
+
+	db.Execute("create table C (TXT varchar(30))")
+	db.Execute("delete from B")
+	dbs := script.NewScript()
+	dbs.DB = db
+	dbs.EcvOptions = &dbx.EcvOptions{}
+
+	px := script.NewParser()
+	if err := px.LoadFile(sqdFile(t, "$ecv_start\n"+ecv+"$ecv_stop\n")); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+
+	if _, err := dbs.Execute(px); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	b.Reset()
+	db.ExportEcvQuery(&b, "B", "select * from B order by ID")
+	db.ExportEcvQuery(&b, "C", "select * from C")
+	if b.String() != ecv {
+		t.Errorf("ExportEcv.roundtrip:\n%s\n%s", ecv, b.String())
+	}
+
+	// timestamps with fractions and zone
+	db.ExecuteF("create table T (ID integer, TS timestamp)")
+	db.ExecuteF("insert into T values (1, '2023-04-01 12:30:15.125+02:00')")
+
+	b.Reset()
+	db.ExportEcv(&b, "T")
+	ecv = b.String()
+	if soll := "@T,ID[bigint],TS[timestamp]\n1^2023-04-01T12:30:15.125+02:00\n"; ecv != soll {
+		t.Errorf("ExportEcv timestamp: soll %q, ist %q", soll, ecv)
+	}
+
+	db.ExecuteF("delete from T")
+	if _, err := db.ImportEcv(strings.NewReader(ecv), nil); err != nil {
+		t.Fatalf("ImportEcv timestamp: %v", err)
+	}
+
+	b.Reset()
+	db.ExportEcv(&b, "T")
+	if b.String() != ecv {
+		t.Errorf("ExportEcv timestamp roundtrip: soll %q, ist %q", ecv, b.String())
+	}
+}
+
+func TestTable(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table T (ID integer, NAME varchar(2000), AMT decimal(10,2))")
+	db.Execute("insert into T values (1, '日本語', 12.5)")
+	db.Execute("insert into T values (22, 'a very long name' || char(10) || 'with newline', -3.25)")
+
+	var b bytes.Buffer
+	q := db.QueryQ("select ID,NAME,AMT from T order by ID")
+	if err := q.WriteTable(&b, &dbx.TableOptions{MaxWidth: 10}); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	q.Close()
+
+	soll := `ID NAME         AMT
+-- ---------- -----
+ 1 日本語      12.5
+22 a very lo… -3.25
+(2 rows)
+`
+	if b.String() != soll {
+		t.Errorf("WriteTable:\n%s", b.String())
+	}
+
+	b.Reset()
+	q = db.QueryQ("select ID,NAME from T where ID=1")
+	q.PrintTo(&b, "box")
+	q.Close()
+
+	soll = `┌────┬────────┐
+│ ID │ NAME   │
+├────┼────────┤
+│  1 │ 日本語 │
+└────┴────────┘
+(1 row)
+`
+	if b.String() != soll {
+		t.Errorf("PrintTo.box:\n%s", b.String())
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table M (ID integer, NAME varchar(20))")
+	db.Execute("insert into M values (1, 'a|b<c>')")
+	db.Execute("insert into M values (2, 'NULL')")
+	db.Execute("insert into M values (3, null)")
+
+	var ar = []struct {
+		frm  string
+		soll string
+	}{
+		{"markdown", "| ID | NAME |\n| ---: | --- |\n| 1 | a\\|b&lt;c&gt; |\n| 2 | NULL |\n| 3 | *NULL* |\n"},
+		{"html", "<table>\n<thead>\n<tr><th style=\"text-align:right\">ID</th><th>NAME</th></tr>\n</thead>\n<tbody>\n" +
+			"<tr><td style=\"text-align:right\">1</td><td>a|b&lt;c&gt;</td></tr>\n" +
+			"<tr><td style=\"text-align:right\">2</td><td>NULL</td></tr>\n" +
+			"<tr><td style=\"text-align:right\">3</td><td class=\"null\"><i>NULL</i></td></tr>\n</tbody>\n</table>\n"},
+		{"xml", xml.Header + "<M xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n" +
+			"  <ROW>\n    <ID>1</ID>\n    <NAME>a|b&lt;c&gt;</NAME>\n  </ROW>\n" +
+			"  <ROW>\n    <ID>2</ID>\n    <NAME>NULL</NAME>\n  </ROW>\n" +
+			"  <ROW>\n    <ID>3</ID>\n    <NAME xsi:nil=\"true\"/>\n  </ROW>\n</M>\n"},
+	}
+
+	for _, a := range ar {
+		var b bytes.Buffer
+		q := db.QueryQ("select ID,NAME from M order by ID")
+		if err := q.PrintTo(&b, a.frm); err != nil {
+			t.Errorf("PrintTo.%s: %v", a.frm, err)
+		}
+		q.Close()
+
+		if b.String() != a.soll {
+			t.Errorf("PrintTo.%s:\n%s", a.frm, b.String())
+		}
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table X (ID integer, AMT decimal(10,2), D date, TS timestamp, NAME varchar(20))")
+	db.Execute("insert into X values (1, 12.5, '2023-05-08', '2023-05-08 12:00:00', 'a<b')")
+	db.Execute("insert into X values (2, null, null, null, null)")
+
+	var b bytes.Buffer
+	x := dbx.NewXLSXWriter(&b)
+	for _, sheet := range []string{"Data", "Fail", "Data"} {
+		sq := "select ID,AMT,D,TS,NAME from X order by ID"
+		if sheet == "Fail" {
+			// integer overflow in the second row
+			sq = "select ID, abs(-9223372036854775806-ID) from X"
+		}
+
+		q := db.QueryQ(sq)
+		err := x.AddSheet(q, &dbx.XLSXOptions{Sheet: sheet})
+		if (err != nil) != (sheet == "Fail") {
+			t.Fatalf("AddSheet %s: %v", sheet, err)
+		}
+		q.Close()
+	}
+
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, _ := f.Open()
+		c, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(c)
+	}
+
+	if !strings.Contains(files["xl/workbook.xml"], `<sheet name="Data" sheetId="1" r:id="rId1"/><sheet name="Data_2" sheetId="2" r:id="rId2"/>`) {
+		t.Errorf("workbook.xml: %s", files["xl/workbook.xml"])
+	}
+
+	// the failed sheet is not listed
+	if !strings.Contains(files["xl/_rels/workbook.xml.rels"], `Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet3.xml"`) {
+		t.Errorf("workbook.xml.rels: %s", files["xl/_rels/workbook.xml.rels"])
+	}
+
+	sheet := files["xl/worksheets/sheet3.xml"]
+	for _, s := range []string{
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">ID</t></is></c>`,
+		`<c r="A2"><v>1</v></c><c r="B2"><v>12.5</v></c><c r="C2" s="2"><v>45054</v></c><c r="D2" s="3"><v>45054.5</v></c>`,
+		`<t xml:space="preserve">a&lt;b</t>`,
+		`<row r="3"><c r="A3"><v>2</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, s) {
+			t.Errorf("sheet3.xml: missing %s in\n%s", s, sheet)
+		}
+	}
+
+	for _, fn := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if err := xml.Unmarshal([]byte(files[fn]), new(interface{})); err != nil || files[fn] == "" {
+			t.Errorf("%s: %v", fn, err)
+		}
+	}
+}
+
+func TestXBase(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table D (ID integer, NAME varchar(10), AMT decimal(10,2), D date, NOTE text)")
+	db.Execute("insert into D values (1, 'Müller', 12.5, '2023-05-09', 'ein langer Text')")
+	db.Execute("insert into D values (2, null, null, null, null)")
+
+	fn := filepath.Join(t.TempDir(), "d.dbf")
+	q := db.QueryQ("select ID,NAME,AMT,D,NOTE from D order by ID")
+	n, err := xbase.Export(q, fn, &xbase.Options{Codepage: "cp850"})
+	q.Close()
+	if err != nil || n != 2 {
+		t.Fatalf("xbase.Export: %d %v", n, err)
+	}
+
+	r, err := xbase.Open(fn, nil)
+	if err != nil {
+		t.Fatalf("xbase.Open: %v", err)
+	}
+
+	fs := fmt.Sprint(r.Fields)
+	if fs != "[{ID 78 20 0} {NAME 77 10 0} {AMT 78 20 4} {D 68 8 0} {NOTE 77 10 0}]" {
+		t.Errorf("xbase.Fields: %s", fs)
+	}
+
+	vals, err := r.Next()
+	if err != nil || fmt.Sprint(vals) != "[1 Müller 12.5000 2023-05-09 ein langer Text]" {
+		t.Errorf("xbase.Next: %v %v", vals, err)
+	}
+	r.Close()
+
+	fc := filepath.Join(t.TempDir(), "c.dbf")
+	w, err := xbase.Create(fc, []xbase.Field{{Name: "NAME", Type: xbase.TypeChar, Len: 4}, {Name: "OK", Type: xbase.TypeLogical, Len: 1}}, &xbase.Options{Codepage: "cp850"})
+	if err != nil {
+		t.Fatalf("xbase.Create: %v", err)
+	}
+	w.Write([]interface{}{"Müller", true})
+	w.Close()
+
+	if r, err = xbase.Open(fc, nil); err == nil {
+		vals, err = r.Next()
+		r.Close()
+	}
+	if err != nil || fmt.Sprint(vals) != "[Müll true]" {
+		t.Errorf("xbase.Char: %v %v", vals, err)
+	}
+
+	// utf8 is cut on a character boundary, memo with 1a as it is
+	fu := filepath.Join(t.TempDir(), "u.dbf")
+	w, err = xbase.Create(fu, []xbase.Field{{Name: "NAME", Type: xbase.TypeChar, Len: 3}, {Name: "BIN", Type: xbase.TypeMemo, Len: 10}}, &xbase.Options{Codepage: "utf8"})
+	if err != nil {
+		t.Fatalf("xbase.Create: %v", err)
+	}
+	w.Write([]interface{}{"aüb", []byte{'a', 0x1A, 'b'}})
+	w.Close()
+
+	if r, err = xbase.Open(fu, nil); err == nil {
+		vals, err = r.Next()
+		r.Close()
+	}
+	if err != nil || fmt.Sprintf("%q", vals) != `["aü" "a\x1ab"]` {
+		t.Errorf("xbase.UTF8: %q %v", vals, err)
+	}
+
+	db.Execute("create table E (ID integer primary key, NAME varchar(10), AMT decimal(10,2), D date)")
+	if _, err = xbase.Import(db, fn, "E", nil); err == nil || !strings.Contains(err.Error(), "NOTE") {
+		t.Errorf("xbase.Import: expected error for unknown column NOTE, got %v", err)
+	}
+
+	n, err = xbase.Import(db, fn, "E", &xbase.ImportOptions{SkipUnknown: true})
+	if err != nil || n != 2 {
+		t.Fatalf("xbase.Import: %d %v", n, err)
+	}
+
+	var b bytes.Buffer
+	q = db.QueryQ("select ID,NAME,AMT,D from E order by ID")
+	q.PrintTo(&b, "csv")
+	q.Close()
+
+	if b.String() != "ID;NAME;AMT;D\n1;Müller;12.5;2023-05-09\n2;;;\n" {
+		t.Errorf("xbase.Import: [%s]", b.String())
+	}
+}
+
+func TestHarbour(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table H (ID integer, NAME varchar(20), D date, X double)")
+	db.Execute("insert into H values (1, 'a\"b''c' || char(10), '2023-05-10', 1e20)")
+	db.Execute("insert into H values (2, ' x^y ', null, null)")
+
+	var b bytes.Buffer
+	q := db.QueryQ("select ID,NAME,D,X from H order by ID")
+	q.PrintTo(&b, "harbour")
+	q.Close()
+
+	soll := "// ID, NAME, D, X\n{ ;\n  { 1, e\"a\\\"b'c\\n\", 0d20230510, 100000000000000000000 }, ;\n  { 2, \" x^y \", NIL, NIL } ;\n}\n"
+	if b.String() != soll {
+		t.Errorf("WriteHarbour:\n%s", b.String())
+	}
+
+	b.Reset()
+	q = db.QueryQ("select ID,D from H order by ID")
+	q.WriteHarbour(&b, &dbx.HarbourOptions{Hash: true, Var: "aData", NullEmpty: true, Date: dbx.HbDateSToD})
+	q.Close()
+
+	soll = "// ID, D\naData := {}\nAAdd( aData, { \"ID\" => 1, \"D\" => hb_SToD(\"20230510\") } )\nAAdd( aData, { \"ID\" => 2, \"D\" => hb_SToD(\"\") } )\n"
+	if b.String() != soll {
+		t.Errorf("WriteHarbour.Hash:\n%s", b.String())
+	}
+
+	if s := dbx.HbString("\x01\xff'\""); s != `e"\x01\xff'\""` {
+		t.Errorf("HbString: %s", s)
+	}
+}
+
+func TestInserts(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.Execute("create table I (ID integer primary key, NAME varchar(20), D date, B blob)")
+	db.Execute("insert into I values (1, 'it''s', '2023-05-11', x'0aff')")
+	db.Execute("insert into I values (2, 'a\\b', null, null)")
+
+	var b bytes.Buffer
+	q := db.QueryQ("select ID,NAME,D,B from I order by ID")
+	if err := q.PrintTo(&b, "sql"); err != nil {
+		t.Errorf("PrintTo.sql: %v", err)
+	}
+	q.Close()
+
+	soll := "insert into I (ID,NAME,D,B) values (1,'it''s','2023-05-11',X'0AFF'),(2,'a\\b',null,null);\n"
+	if b.String() != soll {
+		t.Errorf("PrintTo.sql: [%s]", b.String())
+	}
+
+	// the dump runs on a fresh table
+	db.Execute("delete from I")
+	if err := db.Execute(strings.TrimSpace(b.String())); err != nil || db.QueryI("select count(*) from I where NAME='a\\b'") != 1 {
+		t.Errorf("PrintTo.sql rerun: %v", err)
+	}
+
+	var ar = []struct {
+		d    dbx.Dialect
+		soll string
+	}{
+		{fdb.Dialect{}, "update or insert into I (ID,NAME,D,B) values (1,'it''s','2023-05-11',X'0AFF') matching (ID);\nupdate or insert into I (ID,NAME,D,B) values (2,'a\\b',null,null) matching (ID);\n"},
+		{myd.Dialect{}, "insert into I (ID,NAME,D,B) values (1,'it''s','2023-05-11',X'0AFF'),(2,'a\\\\b',null,null) on duplicate key update NAME=values(NAME),D=values(D),B=values(B);\n"},
+		{msd.Dialect{}, "merge into I as t using (values (1,N'it''s','2023-05-11',0x0AFF),(2,N'a\\b',null,null)) as s (ID,NAME,D,B) on t.ID=s.ID when matched then update set NAME=s.NAME,D=s.D,B=s.B when not matched then insert (ID,NAME,D,B) values (s.ID,s.NAME,s.D,s.B);\n"},
+	}
+
+	for _, a := range ar {
+		b.Reset()
+		q = db.QueryQ("select ID,NAME,D,B from I order by ID")
+		q.WriteInserts(&b, "", &dbx.InsertOptions{Keys: []string{"ID"}, Dialect: a.d})
+		q.Close()
+
+		if b.String() != a.soll {
+			t.Errorf("WriteInserts: [%s]", b.String())
+		}
+	}
+}
+
+func TestSelectInto(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.ExecuteF("create table B (ID integer, NAME varchar(20), PREIS numeric(10,2), DT timestamp, TXT varchar(10))")
+	db.ExecuteF("insert into B values (1, 'eins', 1.5, '2023-04-01 12:30:00', NULL)")
+	db.ExecuteF("insert into B values (2, 'zwei', 2.25, NULL, 'x')")
+
+	type row struct {
+		ID    int
+		Name  string     `db:"NAME"`
+		Preis float64    `db:"preis"`
+		Dt    *time.Time `db:"DT"`
+		Txt   sql.NullString
+	}
+
+	var rr []row
+	if err := db.SelectInto(&rr, "select * from B order by ID"); err != nil {
+		t.Fatalf("SelectInto: %v", err)
+	}
+
+	if len(rr) != 2 || rr[0].Name != "eins" || rr[1].Preis != 2.25 || rr[0].Txt.Valid || rr[1].Txt.String != "x" {
+		t.Errorf("SelectInto: %+v", rr)
+	}
+
+	if rr[0].Dt == nil || rr[0].Dt.Hour() != 12 || rr[1].Dt != nil {
+		t.Errorf("SelectInto: DT %v %v", rr[0].Dt, rr[1].Dt)
+	}
+
+	var pp []*row
+	if err := db.SelectInto(&pp, "select ID,NAME,PREIS,DT,TXT,1 as X from B"); err == nil {
+		t.Errorf("SelectInto: expected unmapped column error")
+	}
+
+	// all missing columns, sorted
+	for i := 0; i < 3; i++ {
+		err := db.SelectInto(&pp, "select ID,NAME from B")
+		if soll := "dbx: column dt,preis,txt missing for dbx_test.row"; err == nil || err.Error() != soll {
+			t.Errorf("SelectInto missing: soll %s, ist %v", soll, err)
+		}
+	}
+
+	// pointers to Scanner are allocated
+	var np []struct {
+		Txt *sql.NullString `db:"TXT"`
+		Dt  *sql.NullTime   `db:"DT"`
+	}
+	if err := db.SelectInto(&np, "select TXT,DT from B order by ID"); err != nil || len(np) != 2 ||
+		np[0].Txt == nil || np[0].Txt.Valid || !np[0].Dt.Valid || np[0].Dt.Time.Hour() != 12 ||
+		np[1].Txt.String != "x" || np[1].Dt.Valid {
+		t.Errorf("SelectInto Scanner pointers: %+v, err: %v", np, err)
+	}
+
+	q := db.QueryQ("select ID,NAME,PREIS,DT,TXT from B where ID=?", 2)
+	var r row
+	if !q.Fetch() || q.ScanStruct(&r) != nil || r.ID != 2 || r.Dt != nil {
+		t.Errorf("ScanStruct: %+v, err: %v", r, q.Err)
+	}
+	q.Close()
+
+	// strings as they are, without trimming and truncation
+	db.ExecuteF("insert into B values (3, '  drei ', 3, '2023-04-01 12:30:00.125', NULL)")
+	var ss []struct {
+		Name string `db:"NAME"`
+		Dt   string `db:"DT"`
+	}
+	if err := db.SelectInto(&ss, "select NAME,DT from B where ID=3"); err != nil || len(ss) != 1 ||
+		ss[0].Name != "  drei " || !strings.Contains(ss[0].Dt, ".125") {
+		t.Errorf("SelectInto string: %+v, err: %v", ss, err)
+	}
+}
+
+func TestFunc(t *testing.T) {
+	var ar = []struct {
+		vs string
+		vi string
+	}{
+		{"-20", "-20"},
+		{"  0- 20  ", "-020"},
+		{" + 25  ", "25"},
+		{"-  40  ", "-40"},
+		{"  30-  ", "-30"},
+	}
+
+	f := dbx.SqxField{Typ: "INT"}
+
+	for _, a := range ar {
+		f.Value = []byte(a.vs)
+		s := f.CleanedString(false)
+
+		if a.vi != s {
+			t.Errorf("CleanString('%s'): soll '%s', ist '%s'", a.vs, a.vi, s)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := fdb.Open("no-constr"); err != dbx.ErrConStr {
+		t.Errorf("fdb.Open: expected ErrConStr, got %v", err)
+	}
+
+	db, err := myd.Open(dbx.DBCfg{User: "u", Pass: "p", Instance: "tcp(127.0.0.1:1)", DBName: "x"})
+	if err != nil {
+		t.Errorf("myd.Open: %v", err)
+		return
+	}
+	db.Close()
+}
+
+func TestCall(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	// the batch of msd.Call for a proc with output parameters
+	q := db.QueryQ("set nocount on;declare @N int;exec P @N output;select @N as N")
+	if q.StmtType != dbx.StmtSelect|dbx.StmtOutf {
+		t.Errorf("Call: soll %d, ist %d", dbx.StmtSelect|dbx.StmtOutf, q.StmtType)
+	}
+
+	// no rows of the failed batch
+	if q.Err == nil || q.Fetch() {
+		t.Errorf("Call: expected error without rows, %v", q.Err)
+	}
+}
+
+func TestDialect(t *testing.T) {
+	var ar = []struct {
+		is   string
+		soll string
+	}{
+		{fdb.Dialect{}.Limit("select * from A", 10, 20), "select * from A rows 21 to 30"},
+		{fdb.Dialect{}.CallProc("P", 2), "execute procedure P(?,?)"},
+		{fdb.Dialect{}.Quote(`A"B`), `"A""B"`},
+		{myd.Dialect{}.Limit("select * from A", 10, 0), "select * from A limit 0,10"},
+		{myd.Dialect{}.Quote("A"), "`A`"},
+		{myd.Dialect{}.TypeName("unsigned int"), "INT"},
+		{pgd.Dialect{}.TypeName("int8"), "BIGINT"},
+		{pgd.Dialect{}.TypeName("TIMESTAMPTZ"), "TIMESTAMP"},
+		{pgd.Dialect{}.CallProc("F", 2), "select * from F($1,$2)"},
+		{msd.Dialect{}.Limit("select * from A", 10, 20), "select * from A order by (select null) offset 20 rows fetch next 10 rows only"},
+		{msd.Dialect{}.TypeName("datetime2"), "TIMESTAMP"},
+		{msd.Dialect{}.TypeName("NVARCHAR"), "VARCHAR"},
+		{msd.Dialect{}.Quote("A]"), "[A]]]"},
+		{msd.Dialect{}.DSN(*dbx.ConStr2DBCfg(`u:p@host\SQLEXPRESS/adb`)), "sqlserver://u:p@host/SQLEXPRESS?database=adb"},
+		{pgd.Dialect{}.DSN(*dbx.ConStr2DBCfg("u:p@host:5432/adb")), "postgres://u:p@host:5432/adb?sslmode=disable"},
+		{fdb.Dialect{}.Insert("A", []string{"ID", "N"}, []string{"ID"}, [][]string{{"?", "?"}}), "update or insert into A (ID,N) values (?,?) matching (ID)"},
+		{myd.Dialect{}.Insert("A", []string{"ID", "N"}, []string{"ID"}, [][]string{{"?", "?"}, {"?", "?"}}), "insert into A (ID,N) values (?,?),(?,?) on duplicate key update N=values(N)"},
+		{pgd.Dialect{}.Insert("A", []string{"ID", "N"}, []string{"ID"}, [][]string{{"$1", "$2"}}), "insert into A (ID,N) values ($1,$2) on conflict (ID) do update set N=excluded.N"},
+		{msd.Dialect{}.Insert("A", []string{"ID", "N"}, []string{"ID"}, [][]string{{"@p1", "@p2"}}), "merge into A as t using (values (@p1,@p2)) as s (ID,N) on t.ID=s.ID when matched then update set N=s.N when not matched then insert (ID,N) values (s.ID,s.N);"},
+		{fdb.Dialect{}.Drop(dbx.ObjTableCol, "A.N"), "alter table A drop N"},
+		{myd.Dialect{}.Drop(dbx.ObjIndex, "A.IX"), "drop index IX on A"},
+		{pgd.Dialect{}.Drop(dbx.ObjTrigger, "A.TR"), "drop trigger TR on A"},
+		{msd.Dialect{}.Drop(dbx.ObjTableCol, "A.N"), "alter table A drop column N"},
+		{sqd.Dialect{}.Drop(dbx.ObjProc, "P"), ""},
+	}
+
+	for _, a := range ar {
+		if a.is != a.soll {
+			t.Errorf("Dialect: soll '%s', ist '%s'", a.soll, a.is)
+		}
+	}
+
+	if (myd.Dialect{}).Exist(dbx.ObjDomain) != "" {
+		t.Errorf("Dialect: mysql has no domains")
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	f := dbx.SqxField{Typ: "DECIMAL", Value: []byte("12.345")}
+	if d, err := f.Decimal(); err != nil || d.String() != "12.345" {
+		t.Errorf("Decimal: %v %v", d, err)
+	}
+
+	if x, err := f.Float64(); err != nil || x != 12.345 {
+		t.Errorf("Float64: %v %v", x, err)
+	}
+
+	if _, err := f.Int(); err == nil {
+		t.Errorf("Int: expected parse error")
+	}
+
+	f = dbx.SqxField{Typ: "TIMESTAMP", Value: []byte("2023-04-01T12:30:15.125+02:00")}
+	if tm, err := f.Time(); err != nil || tm.Nanosecond() != 125000000 || tm.UTC().Hour() != 10 {
+		t.Errorf("Time: %v %v", tm, err)
+	}
+
+	f = dbx.SqxField{Typ: "TIME", Value: []byte("-01:02:03.5")}
+	if d, err := f.Duration(); err != nil || d != -(time.Hour+2*time.Minute+3500*time.Millisecond) {
+		t.Errorf("Duration: %v %v", d, err)
+	}
+
+	f = dbx.SqxField{Typ: "BOOLEAN", Value: []byte("true")}
+	if b, err := f.Bool(); err != nil || !b {
+		t.Errorf("Bool: %v %v", b, err)
+	}
+
+	f.Value = []byte("vielleicht")
+	if _, err := f.Bool(); err == nil {
+		t.Errorf("Bool: expected error")
+	}
+
+	f.Value = nil
+	if f.AsBytes() != nil || f.AsBool() || !f.AsTime().IsZero() {
+		t.Errorf("NULL accessors")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.ExecuteF("create table C (ID integer, NAME varchar(20), PREIS numeric(10,2), OK boolean, DT date, TS timestamp)")
+	db.ExecuteF(`insert into C values (1, ' a"b ', 1.50, 1, '2023-04-01', '2023-04-01 12:30:00.125')`)
+	db.ExecuteF("insert into C values (2, NULL, NULL, 0, NULL, NULL)")
+
+	var b bytes.Buffer
+	q := db.QueryQ("select ID,NAME,PREIS,OK,DT,TS from C order by ID")
+	if err := q.WriteNDJSON(&b, &dbx.JSONOptions{Rename: map[string]string{"ID": "id"}}); err != nil {
+		t.Fatalf("WriteNDJSON: %v", err)
+	}
+	q.Close()
+
+	soll := `{"id":1,"NAME":" a\"b ","PREIS":1.5,"OK":true,"DT":"2023-04-01","TS":"2023-04-01T12:30:00.125Z"}` + "\n" +
+		`{"id":2,"NAME":null,"PREIS":null,"OK":false,"DT":null,"TS":null}` + "\n"
+	if b.String() != soll {
+		t.Errorf("WriteNDJSON: soll\n%s ist\n%s", soll, b.String())
+	}
+
+	q = db.QueryQ("select ID from C order by ID")
+	js, err := q.AsJSON()
+	if err != nil || string(js) != "[\n\t{\n\t\t\"ID\": 1\n\t},\n\t{\n\t\t\"ID\": 2\n\t}\n]\n" {
+		t.Errorf("AsJSON: [%s] %v", js, err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	db := sqdOpen(t)
+	defer db.Close()
+
+	db.ExecuteF("create table D (ID integer, NAME varchar(20), PREIS double, DT date)")
+	db.ExecuteF(`insert into D values (1, 'a"b', 1.5, '2023-04-01')`)
+	db.ExecuteF("insert into D values (2, 'zeile\nzwei', NULL, NULL)")
+	db.ExecuteF("insert into D values (3, 'NULL', NULL, NULL)")
+
+	var b bytes.Buffer
+	q := db.QueryQ("select ID,NAME,PREIS,DT from D order by ID")
+	err := q.WriteCSV(&b, &dbx.CSVOptions{Delimiter: ';', DecimalSep: ',', Null: "NULL", DateFormat: "02.01.2006", BOM: true, LineEnd: "\r\n"})
+	q.Close()
+
+	soll := "\xEF\xBB\xBFID;NAME;PREIS;DT\r\n1;\"a\"\"b\";1,5;01.04.2023\r\n2;\"zeile\nzwei\";NULL;NULL\r\n3;\"NULL\";NULL;NULL\r\n"
+	if err != nil || b.String() != soll {
+		t.Errorf("WriteCSV: %q %v", b.String(), err)
+	}
+
+	q = db.QueryQ("select ID,NAME from D order by ID")
+	ss, err := q.AsDelimitedText(",")
+	q.Close()
+
+	if err != nil || len(ss) != 4 || ss[0] != "ID,NAME" || ss[1] != `1,"a""b"` {
+		t.Errorf("AsDelimitedText: %q %v", ss, err)
+	}
+
+	// raw values, NULL empty and unquoted
+	db.ExecuteF("create table E (ID integer, NAME varchar(20), TS timestamp)")
+	db.ExecuteF("insert into E values (1, ' a ', '2023-04-01 12:30:00.125')")
+	db.ExecuteF("insert into E values (2, '', NULL)")
+
+	b.Reset()
+	q = db.QueryQ("select NAME,TS from E order by ID")
+	err = q.WriteCSV(&b, &dbx.CSVOptions{NoHeader: true, TimeFormat: "2006-01-02 15:04:05.000"})
+	q.Close()
+
+	if soll = " a ,2023-04-01 12:30:00.125\n\"\",\n"; err != nil || b.String() != soll {
+		t.Errorf("WriteCSV raw: soll %q, ist %q %v", soll, b.String(), err)
+	}
+
+	// a value equal to a Null with quote and delimiter is quoted with doubled quotes
+	db.ExecuteF(`insert into E values (3, '"-",', NULL)`)
+
+	b.Reset()
+	q = db.QueryQ("select ID,NAME from E where ID=3")
+	err = q.WriteCSV(&b, &dbx.CSVOptions{NoHeader: true, Null: `"-",`})
+	q.Close()
+
+	if soll = "3,\"\"\"-\"\",\"\n"; err != nil || b.String() != soll {
+		t.Errorf("WriteCSV Null: soll %q, ist %q %v", soll, b.String(), err)
+	}
 }
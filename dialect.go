@@ -0,0 +1,339 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// dialect.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 DDLAtCommit
+// 2023.05.16 TxDDL
+// 2023.05.12 Drop
+// 2023.05.11 Literal
+// 2023.05.04 Insert,MultiRow
+// 2023.04.20 DSN
+// 2023.04.18 init, replaces dbOp and OpExist* keys
+// ----------------------------------------------------------------------------------
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Object # kind of database object for the existence checks
+type Object int
+
+const (
+	// ObjTable # TABLE
+	ObjTable Object = iota
+	// ObjTableCol # TABLE.COLUMN
+	ObjTableCol
+	// ObjIndex # TABLE.INDEX
+	ObjIndex
+	// ObjProc # PROCEDURE
+	ObjProc
+	// ObjFunc # FUNCTION
+	ObjFunc
+	// ObjTrigger # TABLE.TRIGGER
+	ObjTrigger
+	// ObjDomain # DOMAIN
+	ObjDomain
+	// ObjException # EXCEPTION
+	ObjException
+)
+
+// ErrNotSupported #
+var ErrNotSupported = errors.New("dbx: not supported by dialect")
+
+// Dialect # driver specific sql
+type Dialect interface {
+	// DSN # connection string for sql.Open
+	DSN(c DBCfg) string
+
+	// Bind # placeholder style of the driver
+	Bind() BindType
+
+	// Exist # count(*) statement with one bind per name part,
+	// "" if the object kind does not exist in this database
+	Exist(obj Object) string
+
+	// Quote # quoted identifier
+	Quote(ident string) string
+
+	// Limit # sq restricted to limit rows, starting after offset rows
+	Limit(sq string, limit int, offset int) string
+
+	// TypeName # column type name as used by SqxField.Typ
+	TypeName(dbType string) string
+
+	// CallProc # statement calling proc with n binds
+	CallProc(proc string, n int) string
+
+	// Insert # insert of rows (value expressions per column) into table,
+	// upsert matching keys if keys are given
+	Insert(table string, cols []string, keys []string, rows [][]string) string
+
+	// MultiRow # Insert accepts more than one row
+	MultiRow() bool
+
+	// Literal # value of f as sql literal
+	Literal(f *SqxField) string
+
+	// Drop # statement dropping sName (TABLE.NAME for two part objects),
+	// "" if the object kind does not exist in this database
+	Drop(obj Object, sName string) string
+
+	// TxDDL # ddl takes part in transactions and is rolled back with them
+	TxDDL() bool
+
+	// DDLAtCommit # ddl takes effect at commit, objects it creates or changes
+	// cannot be used in the same transaction
+	DDLAtCommit() bool
+}
+
+// BaseDialect # ansi defaults, embedded by the dialects of the dbt packages
+type BaseDialect struct{}
+
+// DSN # user:pass@instance/dbname
+func (BaseDialect) DSN(c DBCfg) string {
+	return DBCfg2ConStr(c)
+}
+
+// Bind #
+func (BaseDialect) Bind() BindType {
+	return BindQuestion
+}
+
+// Exist #
+func (BaseDialect) Exist(obj Object) string {
+	return ""
+}
+
+// Quote #
+func (BaseDialect) Quote(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// Limit #
+func (BaseDialect) Limit(sq string, limit int, offset int) string {
+	if limit > 0 {
+		sq += " limit " + strconv.Itoa(limit)
+	}
+
+	if offset > 0 {
+		sq += " offset " + strconv.Itoa(offset)
+	}
+
+	return sq
+}
+
+// TypeName #
+func (BaseDialect) TypeName(dbType string) string {
+	return strings.ToUpper(dbType)
+}
+
+// CallProc #
+func (BaseDialect) CallProc(proc string, n int) string {
+	return "call " + proc + "(" + Placeholders(BindQuestion, n) + ")"
+}
+
+// Insert # upsert with on conflict (postgres, sqlite)
+func (BaseDialect) Insert(table string, cols []string, keys []string, rows [][]string) string {
+	sq := InsertValues("insert into "+table, cols, rows)
+	if len(keys) == 0 {
+		return sq
+	}
+
+	sq += " on conflict (" + strings.Join(keys, ",") + ")"
+
+	set := UpdateCols(cols, keys, func(c string) string { return c + "=excluded." + c })
+	if set == "" {
+		return sq + " do nothing"
+	}
+
+	return sq + " do update set " + set
+}
+
+// MultiRow #
+func (BaseDialect) MultiRow() bool {
+	return true
+}
+
+// Literal # ansi notation
+func (BaseDialect) Literal(f *SqxField) string {
+	return Literals{}.Literal(f)
+}
+
+// Drop # ansi drop, index and trigger without table
+func (BaseDialect) Drop(obj Object, sName string) string {
+	tbl, name := SplitName(obj, sName)
+
+	switch obj {
+	case ObjTable:
+		return "drop table " + name
+	case ObjTableCol:
+		return "alter table " + tbl + " drop column " + name
+	case ObjIndex:
+		return "drop index " + name
+	case ObjProc:
+		return "drop procedure " + name
+	case ObjFunc:
+		return "drop function " + name
+	case ObjTrigger:
+		return "drop trigger " + name
+	case ObjDomain:
+		return "drop domain " + name
+	case ObjException:
+		return "drop exception " + name
+	}
+
+	return ""
+}
+
+// TxDDL #
+func (BaseDialect) TxDDL() bool {
+	return true
+}
+
+// DDLAtCommit #
+func (BaseDialect) DDLAtCommit() bool {
+	return false
+}
+
+// InsertValues # prefix (c1,c2) values (..),(..)
+func InsertValues(prefix string, cols []string, rows [][]string) string {
+	var sb strings.Builder
+
+	sb.WriteString(prefix + " (" + strings.Join(cols, ",") + ") values ")
+	for i, r := range rows {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("(" + strings.Join(r, ",") + ")")
+	}
+
+	return sb.String()
+}
+
+// UpdateCols # comma separated set(c) for the non key columns
+func UpdateCols(cols []string, keys []string, set func(c string) string) string {
+	var ss []string
+	for _, c := range cols {
+		isKey := false
+		for _, k := range keys {
+			if strings.EqualFold(c, k) {
+				isKey = true
+				break
+			}
+		}
+
+		if !isKey {
+			ss = append(ss, set(c))
+		}
+	}
+
+	return strings.Join(ss, ",")
+}
+
+// Placeholders # n comma separated placeholders
+func Placeholders(bt BindType, n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		if i > 1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(bt.Placeholder(i))
+	}
+
+	return sb.String()
+}
+
+// ObjectParts # number of name parts (TABLE.NAME) of obj
+func ObjectParts(obj Object) int {
+	switch obj {
+	case ObjTableCol, ObjIndex, ObjTrigger:
+		return 2
+	}
+
+	return 1
+}
+
+// SplitName # TABLE and NAME of a two part object, "" and sName otherwise
+func SplitName(obj Object, sName string) (string, string) {
+	if ObjectParts(obj) == 2 {
+		if i := strings.Index(sName, "."); i >= 0 {
+			return sName[:i], sName[i+1:]
+		}
+	}
+
+	return "", sName
+}
+
+// Literals # sql literal notation of a dialect, nil is ansi notation
+type Literals struct {
+	String func(s string) string
+	Bytes  func(b []byte) string
+	Bool   func(b bool) string
+}
+
+// QuoteString # single quoted, inner quotes doubled
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Literal # value of f as sql literal
+func (l Literals) Literal(f *SqxField) string {
+	if f.IsNull() {
+		return "null"
+	}
+
+	str := l.String
+	if str == nil {
+		str = QuoteString
+	}
+
+	switch f.Typ {
+	case "BOOLEAN":
+		if b, err := f.Bool(); err == nil {
+			if l.Bool != nil {
+				return l.Bool(b)
+			}
+			if b {
+				return "true"
+			}
+			return "false"
+		}
+
+	case "BLOB":
+		if l.Bytes != nil {
+			return l.Bytes(f.Value)
+		}
+		return "X'" + strings.ToUpper(hex.EncodeToString(f.Value)) + "'"
+
+	case "DATE":
+		if t, err := f.Time(); err == nil {
+			return "'" + t.Format("2006-01-02") + "'"
+		}
+
+	case "TIMESTAMP", "DATETIME":
+		if t, err := f.Time(); err == nil {
+			return "'" + t.Format("2006-01-02 15:04:05.999999") + "'"
+		}
+	}
+
+	if isNumeric(f.Typ) {
+		s := strings.TrimSpace(string(f.Value))
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s
+		}
+	}
+
+	return str(string(f.Value))
+}
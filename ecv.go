@@ -0,0 +1,636 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// ecv.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 timestamps as rfc 3339
+// 2023.05.17 ShowLineAsEcv escaped as EcvLine, SkipUnknown, Raw lines of the former ShowLineAsEcv
+// 2023.05.05 EcvType,WriteEcv,ExportEcv
+// 2023.05.04 init, ecv reader and import
+// ----------------------------------------------------------------------------------
+
+// ecv format:
+//
+//	@TABLE,ID[int],NAME[char_20],CREATED[date]
+//	1^eins^2023-04-01
+//	2^\N^
+//
+// fields are separated by ^, \ escapes \\, \^, \n, \r, \t and \s (space),
+// any other escaped char is taken as is (\#, \$, \@ at line start, \& at line end).
+// a field \N is NULL, NULL and an empty field are NULL for non character columns.
+// blob values are hex encoded, timestamps rfc 3339. a new @ line starts the next table.
+// raw lines, as written by ShowLineAsEcv before, have no escapes and no \N.
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EcvNull # field value of NULL
+const EcvNull = `\N`
+
+// EcvCol # column of the ecv header, NAME[typ_len] or NAME[numeric_prec_scale]
+type EcvCol struct {
+	Name  string
+	Typ   string
+	Len   int
+	Prec  int
+	Scale int
+}
+
+// EcvHeader # @TABLE,COL[typ],..
+type EcvHeader struct {
+	Table string
+	Cols  []EcvCol
+}
+
+// isChar # character column, NULL and "" are values
+func (c *EcvCol) isChar() bool {
+	switch c.Typ {
+	case "char", "str", "text":
+		return true
+	}
+
+	return false
+}
+
+// ParseEcvHeader #
+func ParseEcvHeader(s string) (*EcvHeader, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '@' {
+		return nil, errors.New("dbx: bad ecv header '" + s + "'")
+	}
+
+	ss := strings.Split(s[1:], ",")
+	h := &EcvHeader{Table: strings.TrimSpace(ss[0])}
+	if h.Table == "" || len(ss) < 2 {
+		return nil, errors.New("dbx: bad ecv header '" + s + "'")
+	}
+
+	for _, cs := range ss[1:] {
+		cs = strings.TrimSpace(cs)
+		c := EcvCol{Name: cs, Typ: "str"}
+
+		if i := strings.IndexByte(cs, '['); i >= 0 {
+			if !strings.HasSuffix(cs, "]") {
+				return nil, errors.New("dbx: bad ecv column '" + cs + "'")
+			}
+
+			c.Name = strings.TrimSpace(cs[:i])
+			tt := strings.Split(strings.ToLower(cs[i+1:len(cs)-1]), "_")
+			c.Typ = tt[0]
+
+			nn := make([]int, len(tt)-1)
+			for j, t := range tt[1:] {
+				n, err := strconv.Atoi(t)
+				if err != nil {
+					return nil, errors.New("dbx: bad ecv column '" + cs + "'")
+				}
+				nn[j] = n
+			}
+
+			switch {
+			case c.Typ == "numeric" && len(nn) > 1:
+				c.Prec, c.Scale = nn[0], nn[1]
+			case c.Typ == "numeric" && len(nn) > 0:
+				c.Prec = nn[0]
+			case len(nn) > 0:
+				c.Len = nn[0]
+			}
+		}
+
+		if c.Name == "" {
+			return nil, errors.New("dbx: bad ecv header '" + s + "'")
+		}
+		h.Cols = append(h.Cols, c)
+	}
+
+	return h, nil
+}
+
+// splitEcv # raw (escaped) fields of a line
+func splitEcv(s string) []string {
+	var ss []string
+
+	b := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '^':
+			ss = append(ss, s[b:i])
+			b = i + 1
+		}
+	}
+
+	return append(ss, s[b:])
+}
+
+// EcvUnescape #
+func EcvUnescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch c = s[i]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 's':
+				c = ' '
+			}
+		}
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+// Values # bind values of an ecv line, nil for NULL
+func (h *EcvHeader) Values(line string) ([]interface{}, error) {
+	return h.values(line, false)
+}
+
+// RawValues # bind values of a raw line without escapes
+func (h *EcvHeader) RawValues(line string) ([]interface{}, error) {
+	return h.values(line, true)
+}
+
+func (h *EcvHeader) values(line string, isRaw bool) ([]interface{}, error) {
+	var ss []string
+	if isRaw {
+		ss = strings.Split(line, "^")
+	} else {
+		ss = splitEcv(line)
+	}
+
+	if len(ss) != len(h.Cols) {
+		return nil, fmt.Errorf("dbx: ecv %s: %d fields, expected %d", h.Table, len(ss), len(h.Cols))
+	}
+
+	vals := make([]interface{}, len(ss))
+	for i, raw := range ss {
+		c := &h.Cols[i]
+		if (raw == EcvNull && !isRaw) || (!c.isChar() && (raw == "" || raw == "NULL")) {
+			continue
+		}
+
+		s := raw
+		if !isRaw {
+			s = EcvUnescape(raw)
+		}
+		switch c.Typ {
+		case "short", "int", "bigint":
+			n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("dbx: ecv %s.%s: %w", h.Table, c.Name, err)
+			}
+			vals[i] = n
+
+		case "double":
+			x, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("dbx: ecv %s.%s: %w", h.Table, c.Name, err)
+			}
+			vals[i] = x
+
+		case "timestamp":
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				vals[i] = t
+			} else {
+				vals[i] = s
+			}
+
+		case "blob":
+			b, err := hex.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("dbx: ecv %s.%s: %w", h.Table, c.Name, err)
+			}
+			vals[i] = b
+
+		default:
+			vals[i] = s
+		}
+	}
+
+	return vals, nil
+}
+
+// EcvReader # rows of one or more ecv tables
+type EcvReader struct {
+	sc     *bufio.Scanner
+	Header *EcvHeader
+	Line   int
+	// Raw # lines without escapes
+	Raw bool
+}
+
+// NewEcvReader #
+func NewEcvReader(r io.Reader) *EcvReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	return &EcvReader{sc: sc}
+}
+
+// Next # values of the next row, io.EOF at end;
+// a @ line replaces Header before its rows are returned
+func (e *EcvReader) Next() ([]interface{}, error) {
+	for e.sc.Scan() {
+		e.Line++
+		s := strings.TrimRight(e.sc.Text(), "\r")
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+
+		if s[0] == '@' {
+			h, err := ParseEcvHeader(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", e.Line, err)
+			}
+			e.Header = h
+			continue
+		}
+
+		if e.Header == nil {
+			return nil, fmt.Errorf("dbx: ecv line %d: missing @ header", e.Line)
+		}
+
+		vals, err := e.Header.values(s, e.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", e.Line, err)
+		}
+
+		return vals, nil
+	}
+
+	if err := e.sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// EcvOptions #
+type EcvOptions struct {
+	// Table # target table, default the table of the header
+	Table string
+	// Rename # ecv column -> table column, "" skips the column
+	Rename map[string]string
+	// Keys # table -> key columns, upsert instead of insert
+	Keys map[string][]string
+	// Batch # rows per insert statement, default 100
+	Batch int
+	// SkipUnknown # columns not in the table are skipped instead of an error
+	SkipUnknown bool
+	// Raw # lines of the former ShowLineAsEcv, no escapes and no \N
+	Raw bool
+}
+
+func lookupFold(m map[string]string, name string) (string, bool) {
+	if s, ok := m[name]; ok {
+		return s, true
+	}
+
+	for k, s := range m {
+		if strings.EqualFold(k, name) {
+			return s, true
+		}
+	}
+
+	return "", false
+}
+
+// ecvLoader # inserts of one ecv table
+type ecvLoader struct {
+	*Inserter
+	idx []int // ecv field per column
+}
+
+func newEcvLoader(ctx context.Context, t *Tx, h *EcvHeader, o *EcvOptions) (*ecvLoader, error) {
+	table := h.Table
+	if o.Table != "" {
+		table = o.Table
+	}
+
+	var keys []string
+	for k, kk := range o.Keys {
+		if strings.EqualFold(k, table) {
+			keys = kk
+		}
+	}
+
+	var names []string
+	var fidx []int
+	for i, c := range h.Cols {
+		name := c.Name
+		if s, ok := lookupFold(o.Rename, name); ok {
+			name = s
+		}
+
+		if name != "" {
+			names = append(names, name)
+			fidx = append(fidx, i)
+		}
+	}
+
+	idx, err := t.KnownCols(table, names, o.SkipUnknown)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &ecvLoader{}
+	var cols []string
+	for _, i := range idx {
+		cols = append(cols, names[i])
+		l.idx = append(l.idx, fidx[i])
+	}
+
+	if len(cols) == 0 {
+		return nil, errors.New("dbx: ecv " + h.Table + ": no columns for table " + table)
+	}
+
+	l.Inserter, err = t.NewInserter(ctx, table, cols, keys, o.Batch)
+
+	return l, err
+}
+
+func (l *ecvLoader) add(vals []interface{}) error {
+	row := make([]interface{}, len(l.idx))
+	for c, i := range l.idx {
+		row[c] = vals[i]
+	}
+
+	return l.Add(row...)
+}
+
+// ImportEcv # rows of r into the tables of the ecv headers, returns the number of rows
+func (t *Tx) ImportEcv(r io.Reader, opts *EcvOptions) (int, error) {
+	return t.ImportEcvContext(context.Background(), r, opts)
+}
+
+// ImportEcvContext #
+func (t *Tx) ImportEcvContext(ctx context.Context, r io.Reader, opts *EcvOptions) (int, error) {
+	o := EcvOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	var l *ecvLoader
+	var h *EcvHeader
+	n := 0
+
+	done := func() error {
+		if l == nil {
+			return nil
+		}
+
+		err := l.Close()
+		n += l.Count()
+
+		return err
+	}
+
+	e := NewEcvReader(r)
+	e.Raw = o.Raw
+	for {
+		vals, err := e.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		if e.Header != h {
+			if err = done(); err != nil {
+				return n, err
+			}
+
+			h = e.Header
+			if l, err = newEcvLoader(ctx, t, h, &o); err != nil {
+				return n, err
+			}
+		}
+
+		if err = l.add(vals); err != nil {
+			return n, err
+		}
+	}
+
+	err := done()
+	return n, err
+}
+
+// ImportEcv # ecv rows of r in one transaction, see Tx.ImportEcv
+func (v *DB) ImportEcv(r io.Reader, opts *EcvOptions) (int, error) {
+	return v.ImportEcvContext(context.Background(), r, opts)
+}
+
+// ImportEcvContext #
+func (v *DB) ImportEcvContext(ctx context.Context, r io.Reader, opts *EcvOptions) (int, error) {
+	n := 0
+	err := v.WithTxContext(ctx, nil, func(t *Tx) error {
+		var err error
+		n, err = t.ImportEcvContext(ctx, r, opts)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// EcvType # ecv column type of f, e.g. char_20 or numeric_18_2
+func (f *SqxField) EcvType() string {
+	switch f.Typ {
+	case "VARYING", "VARCHAR", "CHAR", "TEXT":
+		if f.Len > 0 {
+			return "char_" + strconv.Itoa(f.Len)
+		}
+	case "SHORT", "SMALLINT":
+		return "short"
+	case "INT", "LONG", "INTEGER", "MEDIUMINT", "TINYINT":
+		return "int"
+	case "BIGINT", "INT64":
+		return "bigint"
+	case "TIMESTAMP", "DATETIME":
+		return "timestamp"
+	case "DATE":
+		return "date"
+	case "DECIMAL", "NUMERIC":
+		if f.Prec > 0 {
+			return "numeric_" + strconv.Itoa(f.Prec) + "_" + strconv.Itoa(f.Scale)
+		}
+		return "numeric"
+	case "DOUBLE", "FLOAT", "REAL":
+		return "double"
+	case "BLOB":
+		return "blob"
+	}
+
+	return "str"
+}
+
+var ecvEscaper = strings.NewReplacer(`\`, `\\`, "^", `\^`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// ecvValue # escaped value of f, repl is applied to text before escaping
+func (f *SqxField) ecvValue(repl *strings.Replacer) string {
+	if f.IsNull() {
+		return EcvNull
+	}
+
+	switch f.Typ {
+	case "BLOB":
+		return hex.EncodeToString(f.Value)
+	case "TIMESTAMP", "DATETIME":
+		if t, err := f.Time(); err == nil {
+			return t.Format(time.RFC3339Nano)
+		}
+		return ecvEscaper.Replace(string(f.Value))
+	case "DATE":
+		return f.AsString()
+	}
+
+	if isNumeric(f.Typ) {
+		return strings.TrimSpace(string(f.Value))
+	}
+
+	s := string(f.Value)
+	if f.Typ == "CHAR" {
+		s = strings.TrimRight(s, " ")
+	}
+
+	if repl != nil {
+		s = repl.Replace(s)
+	}
+
+	return ecvEscaper.Replace(s)
+}
+
+// ecvSafe # line survives the script parser and the @ check of the reader
+func ecvSafe(s string) string {
+	i := 0
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+
+	if i < len(s) && strings.IndexByte("#$@", s[i]) >= 0 {
+		s = s[:i] + `\` + s[i:]
+	}
+
+	if n := len(s); n > 0 && s[n-1] == ' ' {
+		s = s[:n-1] + `\s`
+	}
+
+	if strings.HasSuffix(s, "&&") {
+		s = s[:len(s)-1] + `\&`
+	}
+
+	return s
+}
+
+// EcvTitle # @table,COL[typ],.. of q, table "" is QName
+func (q *SQLX) EcvTitle(table string) string {
+	if table == "" {
+		table = q.QName
+	}
+
+	var sb strings.Builder
+	sb.WriteString("@" + table)
+	for i := range q.Fields {
+		f := &q.Fields[i]
+		sb.WriteString("," + f.Name + "[" + f.EcvType() + "]")
+	}
+
+	return sb.String()
+}
+
+// EcvLine # current row of q, escaped
+func (q *SQLX) EcvLine() string {
+	return q.ecvLine(nil)
+}
+
+func (q *SQLX) ecvLine(repl *strings.Replacer) string {
+	var sb strings.Builder
+	for i := range q.Fields {
+		if i > 0 {
+			sb.WriteByte('^')
+		}
+		sb.WriteString(q.Fields[i].ecvValue(repl))
+	}
+
+	return ecvSafe(sb.String())
+}
+
+// WriteEcv # fetch all rows as ecv section of table into w, table "" is QName
+func (q *SQLX) WriteEcv(w io.Writer, table string) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(q.EcvTitle(table) + "\n")
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		bw.WriteString(q.EcvLine() + "\n")
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	return bw.Flush()
+}
+
+// ExportEcvQuery # rows of sq as ecv section of table
+func (v *DB) ExportEcvQuery(w io.Writer, table string, sq string, args ...interface{}) error {
+	q, err := v.ExecuteQ(sq, args...)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	return q.WriteEcv(w, table)
+}
+
+// ExportEcv # whole tables, one ecv section per table
+func (v *DB) ExportEcv(w io.Writer, tables ...string) error {
+	if len(tables) == 0 {
+		return errors.New("dbx: ExportEcv without tables")
+	}
+
+	for _, t := range tables {
+		if err := v.ExportEcvQuery(w, t, "select * from "+t); err != nil {
+			return fmt.Errorf("dbx: ExportEcv %s: %w", t, err)
+		}
+	}
+
+	return nil
+}
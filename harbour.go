@@ -0,0 +1,255 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// harbour.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 strings untrimmed
+// 2023.05.10 init, harbour array/hash literals
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HbDate # harbour notation of DATE and TIMESTAMP values
+type HbDate int
+
+const (
+	// HbDateLiteral # 0d20230510, t"2023-05-10 12:00:00"
+	HbDateLiteral HbDate = iota
+	// HbDateSToD # hb_SToD("20230510"), hb_SToT("20230510120000")
+	HbDateSToD
+	// HbDateString # "2023-05-10", "2023-05-10 12:00:00"
+	HbDateString
+)
+
+// HarbourOptions # zero value: array per row, one literal, NULL as NIL, date literals
+type HarbourOptions struct {
+	// Hash # { "NAME" => value } per row instead of { value, .. }
+	Hash bool
+	// Var # Var := {} and AAdd( Var, row ) per row instead of one literal
+	Var string
+	// NullEmpty # NULL as typed empty value (0, "", .F., empty date) instead of NIL
+	NullEmpty bool
+	// Date # notation of dates and timestamps
+	Date HbDate
+}
+
+// HbString # lossless harbour string literal, e"..." if escapes are needed
+func HbString(s string) string {
+	plain := utf8.ValidString(s)
+	for i := 0; plain && i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			plain = false
+		}
+	}
+
+	if plain && !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+
+	if plain && !strings.Contains(s, `'`) {
+		return `'` + s + `'`
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`e"`)
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && n <= 1:
+			sb.WriteString(`\x` + strconv.FormatUint(uint64(s[i])|0x100, 16)[1:])
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '"':
+			sb.WriteString(`\"`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			sb.WriteString(`\x` + strconv.FormatUint(uint64(r)|0x100, 16)[1:])
+		default:
+			sb.WriteString(s[i : i+n])
+		}
+		i += n
+	}
+	sb.WriteString(`"`)
+
+	return sb.String()
+}
+
+func (o *HarbourOptions) date(f *SqxField) string {
+	ts := f.Typ != "DATE"
+
+	if f.IsNull() {
+		switch {
+		case o.Date == HbDateString:
+			return `""`
+		case ts:
+			return `hb_SToT("")`
+		case o.Date == HbDateSToD:
+			return `hb_SToD("")`
+		}
+		return "0d00000000"
+	}
+
+	t, err := f.Time()
+	if err != nil {
+		return HbString(string(f.Value))
+	}
+
+	switch {
+	case o.Date == HbDateString && ts:
+		return `"` + t.Format("2006-01-02 15:04:05") + `"`
+	case o.Date == HbDateString:
+		return `"` + t.Format("2006-01-02") + `"`
+	case o.Date == HbDateSToD && ts:
+		return `hb_SToT("` + t.Format("20060102150405") + `")`
+	case o.Date == HbDateSToD:
+		return `hb_SToD("` + t.Format("20060102") + `")`
+	case ts:
+		return `t"` + t.Format("2006-01-02 15:04:05") + `"`
+	}
+
+	return "0d" + t.Format("20060102")
+}
+
+// hbValue # harbour literal of f
+func (o *HarbourOptions) hbValue(f *SqxField) string {
+	switch f.Typ {
+	case "DATE", "TIMESTAMP", "DATETIME":
+		if f.IsNull() && !o.NullEmpty {
+			return "NIL"
+		}
+		return o.date(f)
+	}
+
+	if f.IsNull() {
+		if !o.NullEmpty {
+			return "NIL"
+		}
+
+		switch {
+		case isNumeric(f.Typ):
+			return "0"
+		case f.Typ == "BOOLEAN":
+			return ".F."
+		}
+		return `""`
+	}
+
+	switch {
+	case f.Typ == "DOUBLE" || f.Typ == "FLOAT" || f.Typ == "REAL":
+		// harbour has no exponent notation
+		if x, err := f.Float64(); err == nil {
+			return strconv.FormatFloat(x, 'f', -1, 64)
+		}
+
+	case isNumeric(f.Typ):
+		s := strings.TrimSpace(string(f.Value))
+		if _, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "eE") {
+			return s
+		}
+
+	case f.Typ == "BOOLEAN":
+		if b, err := f.Bool(); err == nil {
+			if b {
+				return ".T."
+			}
+			return ".F."
+		}
+
+	case f.Typ == "BLOB":
+		return HbString(string(f.Value))
+	}
+
+	return HbString(string(f.Value))
+}
+
+func (q *SQLX) hbRow(o *HarbourOptions) string {
+	var sb strings.Builder
+
+	sb.WriteString("{ ")
+	for i := range q.Fields {
+		f := &q.Fields[i]
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		if o.Hash {
+			sb.WriteString(HbString(f.Name) + " => ")
+		}
+		sb.WriteString(o.hbValue(f))
+	}
+	sb.WriteString(" }")
+
+	return sb.String()
+}
+
+// WriteHarbour # fetch all rows as harbour source fragment for #include
+func (q *SQLX) WriteHarbour(w io.Writer, opts *HarbourOptions) error {
+	o := HarbourOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	bw := bufio.NewWriter(w)
+
+	names := make([]string, len(q.Fields))
+	for i, f := range q.Fields {
+		names[i] = f.Name
+	}
+	bw.WriteString("// " + strings.Join(names, ", ") + "\n")
+
+	if o.Var != "" {
+		bw.WriteString(o.Var + " := {}\n")
+	} else {
+		bw.WriteString("{")
+	}
+
+	n := 0
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		row := q.hbRow(&o)
+		switch {
+		case o.Var != "":
+			bw.WriteString("AAdd( " + o.Var + ", " + row + " )\n")
+		case n > 0:
+			bw.WriteString(", ;\n  " + row)
+		default:
+			bw.WriteString(" ;\n  " + row)
+		}
+		n++
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	if o.Var == "" {
+		if n > 0 {
+			bw.WriteString(" ;\n")
+		}
+		bw.WriteString("}\n")
+	}
+
+	return bw.Flush()
+}
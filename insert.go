@@ -0,0 +1,172 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// insert.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 KnownCols with error for unknown columns
+// 2023.05.09 init, Inserter from the ecv loader, KnownCols
+// ----------------------------------------------------------------------------------
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxInsertParams # binds per statement, sql server allows 2100
+const maxInsertParams = 2000
+
+// Inserter # batched, prepared inserts into one table, upserts if Keys are given
+type Inserter struct {
+	Table string
+	Cols  []string
+	Keys  []string
+
+	ctx   context.Context
+	tx    *Tx
+	d     Dialect
+	batch int
+	rows  [][]interface{}
+	sq    string
+	stmt  *sql.Stmt
+	n     int
+}
+
+// NewInserter # batch rows per statement, default 100,
+// one if the dialect has no multi row insert
+func (t *Tx) NewInserter(ctx context.Context, table string, cols []string, keys []string, batch int) (*Inserter, error) {
+	if len(cols) == 0 {
+		return nil, errors.New("dbx: no columns for table " + table)
+	}
+
+	b := &Inserter{Table: table, Cols: cols, Keys: keys, ctx: ctx, tx: t, d: t.db.Dialect, batch: batch}
+	if b.batch <= 0 {
+		b.batch = 100
+	}
+
+	if !b.d.MultiRow() {
+		b.batch = 1
+	} else if n := maxInsertParams / len(cols); b.batch > n {
+		b.batch = n
+	}
+
+	return b, nil
+}
+
+// Add # one row, one value per column
+func (b *Inserter) Add(vals ...interface{}) error {
+	if len(vals) != len(b.Cols) {
+		return fmt.Errorf("dbx: insert %s: %d values, expected %d", b.Table, len(vals), len(b.Cols))
+	}
+
+	b.rows = append(b.rows, vals)
+	if len(b.rows) >= b.batch {
+		return b.Flush()
+	}
+
+	return nil
+}
+
+// Flush # pending rows
+func (b *Inserter) Flush() error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+
+	bt := b.d.Bind()
+	nc := len(b.Cols)
+
+	rows := make([][]string, len(b.rows))
+	args := make([]interface{}, 0, len(b.rows)*nc)
+	for r, vals := range b.rows {
+		rows[r] = make([]string, nc)
+		for c := range b.Cols {
+			rows[r][c] = bt.Placeholder(r*nc + c + 1)
+		}
+		args = append(args, vals...)
+	}
+
+	sq := b.d.Insert(b.Table, b.Cols, b.Keys, rows)
+	if sq != b.sq {
+		if err := b.release(); err != nil {
+			return err
+		}
+
+		stmt, err := b.tx.PrepareContext(b.ctx, sq)
+		if err != nil {
+			return fmt.Errorf("dbx: insert %s: %w", b.Table, err)
+		}
+		b.sq, b.stmt = sq, stmt
+	}
+
+	if _, err := b.stmt.ExecContext(b.ctx, args...); err != nil {
+		return fmt.Errorf("dbx: insert %s: %w", b.Table, err)
+	}
+
+	b.n += len(b.rows)
+	b.rows = b.rows[:0]
+
+	return nil
+}
+
+func (b *Inserter) release() error {
+	if b.stmt == nil {
+		return nil
+	}
+
+	err := b.stmt.Close()
+	b.stmt, b.sq = nil, ""
+
+	return err
+}
+
+// Close # flush and release the statement
+func (b *Inserter) Close() error {
+	err := b.Flush()
+	if e := b.release(); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// Count # rows written
+func (b *Inserter) Count() int {
+	return b.n
+}
+
+// KnownCols # indexes of the cols existing in table, all if the dialect cannot tell;
+// cols unknown to the table are an error, unless skip
+func (t *Tx) KnownCols(table string, cols []string, skip bool) ([]int, error) {
+	check := t.db.Dialect.Exist(ObjTableCol) != ""
+
+	var idx []int
+	var unknown []string
+	for i, c := range cols {
+		if !check || t.ExistTableCol(table+"."+c) {
+			idx = append(idx, i)
+			continue
+		}
+
+		if t.Err != nil {
+			return nil, fmt.Errorf("dbx: columns of %s: %w", table, t.Err)
+		}
+		unknown = append(unknown, c)
+	}
+
+	if len(unknown) > 0 && !skip {
+		return nil, errors.New("dbx: unknown columns of " + table + ": " + strings.Join(unknown, ","))
+	}
+
+	return idx, nil
+}
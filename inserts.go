@@ -0,0 +1,108 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// inserts.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.11 init, insert/upsert dump
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxInsertRows # row constructors per statement, sql server allows 1000
+const maxInsertRows = 1000
+
+// InsertOptions #
+type InsertOptions struct {
+	// Keys # upsert matching these columns instead of insert
+	Keys []string
+	// Batch # rows per statement, default 100, one if the dialect has no multi row insert
+	Batch int
+	// Dialect # of the target database, default the one of the query
+	Dialect Dialect
+}
+
+// WriteInserts # fetch all rows as insert statements into table, table "" is QName
+func (q *SQLX) WriteInserts(w io.Writer, table string, opts *InsertOptions) error {
+	o := InsertOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if table == "" {
+		table = q.QName
+	}
+
+	if table == "" {
+		return errors.New("dbx: WriteInserts without table")
+	}
+
+	d := o.Dialect
+	if d == nil {
+		d = q.db.Dialect
+	}
+
+	if o.Batch <= 0 {
+		o.Batch = 100
+	}
+
+	if !d.MultiRow() {
+		o.Batch = 1
+	} else if o.Batch > maxInsertRows {
+		o.Batch = maxInsertRows
+	}
+
+	cols := make([]string, len(q.Fields))
+	for i, f := range q.Fields {
+		cols[i] = f.Name
+	}
+
+	bw := bufio.NewWriter(w)
+	var rows [][]string
+
+	flush := func() {
+		if len(rows) == 0 {
+			return
+		}
+
+		sq := strings.TrimSuffix(d.Insert(table, cols, o.Keys, rows), ";")
+		bw.WriteString(sq + ";\n")
+		rows = rows[:0]
+	}
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		row := make([]string, len(q.Fields))
+		for i := range q.Fields {
+			row[i] = d.Literal(&q.Fields[i])
+		}
+
+		rows = append(rows, row)
+		if len(rows) >= o.Batch {
+			flush()
+		}
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	flush()
+
+	return bw.Flush()
+}
@@ -0,0 +1,190 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// json.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 timestamps as rfc 3339, strings untrimmed
+// 2023.04.30 init, streaming json and ndjson
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONOptions #
+type JSONOptions struct {
+	// Indent # of the objects in a json array, "" is compact
+	Indent string
+	// Rename # column name -> json key
+	Rename map[string]string
+}
+
+func (o *JSONOptions) key(name string) string {
+	if o != nil {
+		if k, ok := o.Rename[name]; ok {
+			return k
+		}
+	}
+
+	return name
+}
+
+func isNumeric(typ string) bool {
+	switch typ {
+	case "SHORT", "INT", "MEDIUMINT", "TINYINT", "BIGINT", "INT128",
+		"DECIMAL", "NUMERIC", "DOUBLE", "FLOAT", "REAL":
+		return true
+	}
+
+	return false
+}
+
+// jsonValue # typed json of the field value
+func (f *SqxField) jsonValue() []byte {
+	if f.IsNull() {
+		return []byte("null")
+	}
+
+	s := strings.TrimSpace(string(f.Value))
+	switch {
+	case isNumeric(f.Typ):
+		if len(s) > 0 && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s)) {
+			return []byte(s)
+		}
+
+	case f.Typ == "BOOLEAN":
+		if b, err := f.Bool(); err == nil {
+			if b {
+				return []byte("true")
+			}
+			return []byte("false")
+		}
+
+	case f.Typ == "BLOB":
+		if !utf8.Valid(f.Value) {
+			s = base64.StdEncoding.EncodeToString(f.Value)
+		} else {
+			s = string(f.Value)
+		}
+		b, _ := json.Marshal(s)
+		return b
+
+	case f.Typ == "TIMESTAMP" || f.Typ == "DATETIME":
+		if t, err := f.Time(); err == nil {
+			s = t.Format(time.RFC3339Nano)
+		}
+
+	case f.Typ == "DATE":
+		if t, err := f.Time(); err == nil {
+			s = t.Format("2006-01-02")
+		}
+
+	default:
+		s = string(f.Value)
+	}
+
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// writeJSONRow # one object in column order
+func (q *SQLX) writeJSONRow(w *bufio.Writer, opts *JSONOptions, indent string) {
+	w.WriteByte('{')
+	for i := range q.Fields {
+		f := &q.Fields[i]
+		if i > 0 {
+			w.WriteByte(',')
+		}
+
+		if indent != "" {
+			w.WriteString("\n" + indent + indent)
+		}
+
+		k, _ := json.Marshal(opts.key(f.Name))
+		w.Write(k)
+		w.WriteByte(':')
+		if indent != "" {
+			w.WriteByte(' ')
+		}
+		w.Write(f.jsonValue())
+	}
+
+	if indent != "" {
+		w.WriteString("\n" + indent)
+	}
+	w.WriteByte('}')
+}
+
+// WriteJSON # fetch all rows as json array, row by row into w
+func (q *SQLX) WriteJSON(w io.Writer, opts *JSONOptions) error {
+	indent := ""
+	if opts != nil {
+		indent = opts.Indent
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+
+	n := 0
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+
+		if indent != "" {
+			bw.WriteString("\n" + indent)
+		}
+
+		q.writeJSONRow(bw, opts, indent)
+		n++
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	if indent != "" && n > 0 {
+		bw.WriteByte('\n')
+	}
+	bw.WriteString("]\n")
+
+	return bw.Flush()
+}
+
+// WriteNDJSON # fetch all rows, one json object per line
+func (q *SQLX) WriteNDJSON(w io.Writer, opts *JSONOptions) error {
+	bw := bufio.NewWriter(w)
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		q.writeJSONRow(bw, opts, "")
+		bw.WriteByte('\n')
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	return bw.Flush()
+}
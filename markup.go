@@ -0,0 +1,226 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// markup.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.07 init, markdown, html and xml
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/xml"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// markupText # value of f as text, binary blobs as [n bytes]
+func (f *SqxField) markupText() string {
+	if f.Typ == "BLOB" && !utf8.Valid(f.Value) {
+		return "[" + strconv.Itoa(len(f.Value)) + " bytes]"
+	}
+
+	return f.AsString()
+}
+
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`",
+	"<", "&lt;", ">", "&gt;", "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// WriteMarkdown # fetch all rows as markdown (gfm) table, NULL as *NULL*
+func (q *SQLX) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("|")
+	for _, f := range q.Fields {
+		bw.WriteString(" " + mdEscaper.Replace(f.Name) + " |")
+	}
+
+	bw.WriteString("\n|")
+	for _, f := range q.Fields {
+		if isNumeric(f.Typ) {
+			bw.WriteString(" ---: |")
+		} else {
+			bw.WriteString(" --- |")
+		}
+	}
+	bw.WriteString("\n")
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		bw.WriteString("|")
+		for i := range q.Fields {
+			f := &q.Fields[i]
+			if f.IsNull() {
+				bw.WriteString(" *NULL* |")
+			} else {
+				bw.WriteString(" " + mdEscaper.Replace(f.markupText()) + " |")
+			}
+		}
+		bw.WriteString("\n")
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	return bw.Flush()
+}
+
+// WriteHTML # fetch all rows as html table, NULL as <td class="null"><i>NULL</i></td>
+func (q *SQLX) WriteHTML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	td := make([]string, len(q.Fields))
+	bw.WriteString("<table>\n<thead>\n<tr>")
+	for i, f := range q.Fields {
+		td[i] = "<td>"
+		th := "<th>"
+		if isNumeric(f.Typ) {
+			td[i] = `<td style="text-align:right">`
+			th = `<th style="text-align:right">`
+		}
+		bw.WriteString(th + html.EscapeString(f.Name) + "</th>")
+	}
+	bw.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		bw.WriteString("<tr>")
+		for i := range q.Fields {
+			f := &q.Fields[i]
+			if f.IsNull() {
+				bw.WriteString(`<td class="null"><i>NULL</i></td>`)
+				continue
+			}
+
+			s := strings.ReplaceAll(html.EscapeString(f.markupText()), "\n", "<br>")
+			bw.WriteString(td[i] + s + "</td>")
+		}
+		bw.WriteString("</tr>\n")
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	bw.WriteString("</tbody>\n</table>\n")
+
+	return bw.Flush()
+}
+
+// XMLOptions #
+type XMLOptions struct {
+	// Root # element of the document, default QName or ROWS
+	Root string
+	// Row # element per row, default ROW
+	Row string
+}
+
+// xmlName # column name as xml element name
+func xmlName(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		ok := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r > 127
+		if i > 0 {
+			ok = ok || r == '-' || r == '.' || (r >= '0' && r <= '9')
+		}
+
+		if !ok {
+			if i == 0 && r >= '0' && r <= '9' {
+				sb.WriteRune('_')
+				sb.WriteRune(r)
+				continue
+			}
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+
+	if sb.Len() == 0 {
+		return "_"
+	}
+
+	return sb.String()
+}
+
+// WriteXML # fetch all rows as xml, one element per column,
+// NULL as empty element with xsi:nil="true", binary blobs base64
+func (q *SQLX) WriteXML(w io.Writer, opts *XMLOptions) error {
+	o := XMLOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Root == "" {
+		o.Root = q.QName
+	}
+
+	if o.Root == "" {
+		o.Root = "ROWS"
+	}
+
+	if o.Row == "" {
+		o.Row = "ROW"
+	}
+
+	root, row := xmlName(o.Root), xmlName(o.Row)
+	names := make([]string, len(q.Fields))
+	for i, f := range q.Fields {
+		names[i] = xmlName(f.Name)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString("<" + root + ` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` + "\n")
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		bw.WriteString("  <" + row + ">\n")
+		for i := range q.Fields {
+			f := &q.Fields[i]
+			if f.IsNull() {
+				bw.WriteString("    <" + names[i] + ` xsi:nil="true"/>` + "\n")
+				continue
+			}
+
+			s := f.AsString()
+			if f.Typ == "BLOB" && !utf8.Valid(f.Value) {
+				s = base64.StdEncoding.EncodeToString(f.Value)
+			}
+
+			bw.WriteString("    <" + names[i] + ">")
+			xml.EscapeText(bw, []byte(s))
+			bw.WriteString("</" + names[i] + ">\n")
+		}
+		bw.WriteString("  </" + row + ">\n")
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	bw.WriteString("</" + root + ">\n")
+
+	return bw.Flush()
+}
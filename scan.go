@@ -0,0 +1,309 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// scan.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 string fields untrimmed, time.Time.String layout, pointers to Scanner, missing columns sorted
+// 2023.04.26 init, ScanStruct,SelectInto
+// ----------------------------------------------------------------------------------
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts # as delivered by the drivers
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999 -0700 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("dbx: bad time value '" + s + "'")
+}
+
+// structFields # lower(db tag or field name) -> field index path
+func structFields(t reflect.Type, path []int, m map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		p := append(append([]int{}, path...), i)
+		if sf.Anonymous && tag == "" && sf.Type.Kind() == reflect.Struct {
+			structFields(sf.Type, p, m)
+			continue
+		}
+
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if tag == "" {
+			tag = sf.Name
+		}
+		m[strings.ToLower(tag)] = p
+	}
+}
+
+// structMap # field index path per column
+func structMap(t reflect.Type, fields []SqxField) ([][]int, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("dbx: destination is not a struct: " + t.String())
+	}
+
+	m := map[string][]int{}
+	structFields(t, nil, m)
+
+	idx := make([][]int, len(fields))
+	for i, f := range fields {
+		fn := strings.ToLower(f.Name)
+		p, ok := m[fn]
+		if !ok {
+			return nil, fmt.Errorf("dbx: column %s not mapped in %s", f.Name, t.String())
+		}
+		idx[i] = p
+		delete(m, fn)
+	}
+
+	if len(m) > 0 {
+		missing := make([]string, 0, len(m))
+		for fn := range m {
+			missing = append(missing, fn)
+		}
+		sort.Strings(missing)
+
+		return nil, fmt.Errorf("dbx: column %s missing for %s", strings.Join(missing, ","), t.String())
+	}
+
+	return idx, nil
+}
+
+// setField # value of f into v
+func setField(v reflect.Value, f *SqxField) error {
+	if v.Kind() == reflect.Ptr && v.Type().Implements(scannerType) {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return scanField(v.Interface().(sql.Scanner), f)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if f.IsNull() {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(scannerType) {
+		return scanField(v.Addr().Interface().(sql.Scanner), f)
+	}
+
+	if f.IsNull() {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	s := strings.TrimSpace(string(f.Value))
+
+	if v.Type() == timeType {
+		t, err := parseTime(s)
+		if err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(f.Value))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return errors.New("dbx: unsupported field type " + v.Type().String())
+		}
+		v.SetBytes(append([]byte{}, f.Value...))
+
+	default:
+		return errors.New("dbx: unsupported field type " + v.Type().String())
+	}
+
+	return nil
+}
+
+// scanField # value of f into sc, time for sql.NullTime
+func scanField(sc sql.Scanner, f *SqxField) error {
+	if f.IsNull() {
+		return sc.Scan(nil)
+	}
+
+	if _, ok := sc.(*sql.NullTime); ok {
+		t, err := parseTime(string(f.Value))
+		if err != nil {
+			return err
+		}
+		return sc.Scan(t)
+	}
+
+	return sc.Scan(f.Value)
+}
+
+func (q *SQLX) scanInto(v reflect.Value, idx [][]int) error {
+	for i := range q.Fields {
+		f := &q.Fields[i]
+		if err := setField(v.FieldByIndex(idx[i]), f); err != nil {
+			return fmt.Errorf("dbx: column %s: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ScanStruct # current row into dst (*struct), columns are mapped
+// to the fields by `db:"COLUMN"` tag or field name, case insensitive
+func (q *SQLX) ScanStruct(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("dbx: ScanStruct needs a pointer to a struct")
+	}
+
+	v = v.Elem()
+	idx, err := structMap(v.Type(), q.Fields)
+	if err != nil {
+		return err
+	}
+
+	return q.scanInto(v, idx)
+}
+
+// scanAll # all rows of q into dst (*[]T or *[]*T)
+func (q *SQLX) scanAll(dst interface{}) error {
+	defer q.Close()
+
+	sv := reflect.ValueOf(dst)
+	if sv.Kind() != reflect.Ptr || sv.Elem().Kind() != reflect.Slice {
+		return errors.New("dbx: SelectInto needs a pointer to a slice")
+	}
+
+	sv = sv.Elem()
+	et := sv.Type().Elem()
+	isPtr := et.Kind() == reflect.Ptr
+	if isPtr {
+		et = et.Elem()
+	}
+
+	idx, err := structMap(et, q.Fields)
+	if err != nil {
+		return err
+	}
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		e := reflect.New(et)
+		if err = q.scanInto(e.Elem(), idx); err != nil {
+			return err
+		}
+
+		if isPtr {
+			sv.Set(reflect.Append(sv, e))
+		} else {
+			sv.Set(reflect.Append(sv, e.Elem()))
+		}
+	}
+
+	return q.Err
+}
+
+// SelectInto # rows of sq into dst (*[]T or *[]*T), see ScanStruct
+func (v *DB) SelectInto(dst interface{}, sq string, args ...interface{}) error {
+	return v.SelectIntoContext(context.Background(), dst, sq, args...)
+}
+
+// SelectIntoContext #
+func (v *DB) SelectIntoContext(ctx context.Context, dst interface{}, sq string, args ...interface{}) error {
+	q, err := v.ExecuteQContext(ctx, sq, args...)
+	if err != nil {
+		return err
+	}
+
+	return q.scanAll(dst)
+}
+
+// SelectInto # rows of sq into dst (*[]T or *[]*T), see ScanStruct
+func (t *Tx) SelectInto(dst interface{}, sq string, args ...interface{}) error {
+	q, err := t.ExecuteQ(sq, args...)
+	if err != nil {
+		return err
+	}
+
+	return q.scanAll(dst)
+}
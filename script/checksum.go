@@ -0,0 +1,144 @@
+package script
+
+// ----------------------------------------------------------------------------------
+// checksum.go for Go's dbx.script package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.16 checksumN of the commands done
+// 2023.05.14 init, checksum of dbu blocks
+// ----------------------------------------------------------------------------------
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// blockTokens # tokens up to the $dbu_end of a block
+func blockTokens(tks []*Token) []*Token {
+	for i, tk := range tks {
+		if tk.ID == TkDbuEnd {
+			return tks[:i]
+		}
+	}
+
+	return tks
+}
+
+// cmdCount # commands of a token, an ecv block is one
+func cmdCount(tk *Token) int {
+	if tk.ID == TkEcv {
+		return 1
+	}
+
+	return len(tk.Cmds)
+}
+
+// checksum # sha256 of the tokens, sql without comments and with
+// whitespace outside of quotes as one blank, ecv data as it is
+func checksum(tks []*Token) string {
+	return checksumN(tks, -1)
+}
+
+// checksumN # of the first n commands, all if n < 0
+func checksumN(tks []*Token, n int) string {
+	h := sha256.New()
+
+	for _, tk := range tks {
+		if n == 0 {
+			break
+		}
+
+		h.Write([]byte(tk.Key))
+
+		// the condition of $if is not part of the commands
+		if tk.ID == TkIf {
+			for _, f := range tk.Fields {
+				h.Write([]byte(" " + f.Key))
+			}
+		}
+		h.Write([]byte{'\n'})
+
+		for i := range tk.Cmds {
+			if n >= 0 && tk.ID != TkEcv && i >= n {
+				break
+			}
+
+			s := tk.GetData(i)
+			if tk.ID != TkEcv {
+				s = normalize(s)
+			}
+
+			h.Write([]byte(s))
+			h.Write([]byte{'\n'})
+		}
+
+		if n > 0 {
+			if n -= cmdCount(tk); n <= 0 {
+				break
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalize # sql without -- and /* */ comments, whitespace outside of quotes as one blank
+func normalize(s string) string {
+	var sb strings.Builder
+
+	blank := false
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if quote != 0 {
+			sb.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			blank = true
+			continue
+
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			if j := strings.Index(s[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(s)
+			}
+			blank = true
+			continue
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			blank = true
+			continue
+		}
+
+		if blank && sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		blank = false
+
+		if c == '\'' || c == '"' {
+			quote = c
+		}
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
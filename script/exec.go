@@ -11,6 +11,12 @@ package script
 // ----------------------------------------------------------------------------------
 // HISTORY
 // ----------------------------------------------------------------------------------
+// 2023.05.17 SaveCheckpoint for ddl applied at commit, Blocks: $dbu_start/$dbu_end to ExecCmd without, raw ecv blocks by default
+// 2023.05.16 TxScope,Begin,Commit,Rollback,SaveCheckpoint,LoadCheckpoint
+// 2023.05.15 Plan,Translate
+// 2023.05.14 checksum of dbu blocks,CheckStep
+// 2023.05.13 Version,$dbu_start blocks,SaveStep
+// 2023.05.04 DB,ImportEcv
 // 2023.04.02 ExistDom,ExistExc
 // 2020.07.19 TkField abfragen
 // 2020.06.06 New,VersInfo
@@ -19,13 +25,18 @@ package script
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/waldurbas/dbx"
 )
 
-// VersInfo #
+// VersInfo # Vers is taken from Dbu, if it is zero; Dbu is 0 beyond 99 updates
 type VersInfo struct {
 	Dbu  int
+	Vers Version
 	App  string
 	Chg  string
 	Show bool
@@ -46,6 +57,35 @@ type DbScript struct {
 	ExistDomain    func(sName string) bool
 	ExistException func(sName string) bool
 	SaveVers       func(v int) error
+	// SaveStep # applied dbu step, SaveVers(Dbu) if nil, an error beyond 99 updates
+	SaveStep func(st *Step) error
+	// CheckStep # dbu block skipped as already applied, to compare the checksum
+	CheckStep func(st *Step) error
+	// Translate # statements of a command for Plan
+	Translate func(cmd string) ([]string, error)
+
+	// Blocks # $dbu_start/$dbu_end blocks with versions, checkpoints and SaveStep,
+	// set by NewRunner; passed to ExecCmd as before otherwise
+	Blocks bool
+
+	// TxScope # transactions by Begin, Commit and Rollback
+	TxScope  TxScope
+	Begin    func() error
+	Commit   func() error
+	Rollback func() error
+	// SaveCheckpoint # after every command of a dbu block, for databases without rollback of ddl
+	// or applying it at commit
+	SaveCheckpoint func(cp *Checkpoint) error
+	// LoadCheckpoint # of a dbu block to resume, nil if there is none
+	LoadCheckpoint func(v Version) (*Checkpoint, error)
+
+	// DB # target of the ecv blocks, if ExecEcv is nil
+	DB *dbx.DB
+	// EcvOptions # of the ecv blocks, raw lines of the former ShowLineAsEcv if nil
+	EcvOptions *dbx.EcvOptions
+
+	plan *Plan
+	inTx bool
 }
 
 // NewScript #
@@ -53,10 +93,30 @@ func NewScript() *DbScript {
 	return &DbScript{Vinfo: VersInfo{App: "none", Hide: true}}
 }
 
+// ImportEcv # default of ExecEcv, loads the block into DB
+func (dbs *DbScript) ImportEcv(a *Lines) error {
+	if dbs.DB == nil {
+		return errors.New("DbScript.ImportEcv: no database")
+	}
+
+	_, err := dbs.DB.ImportEcv(a.Reader(), dbs.ecvOptions())
+	return err
+}
+
+// ecvOptions # EcvOptions, Raw if nil
+func (dbs *DbScript) ecvOptions() *dbx.EcvOptions {
+	if dbs.EcvOptions == nil {
+		return &dbx.EcvOptions{Raw: true}
+	}
+
+	return dbs.EcvOptions
+}
+
 // LoadFile #
 func (dbs *DbScript) LoadFile(fileName string) (*Parser, error) {
 
 	px := NewParser()
+	px.Blocks = dbs.Blocks
 
 	err := px.LoadFile(fileName)
 	if err != nil {
@@ -66,19 +126,111 @@ func (dbs *DbScript) LoadFile(fileName string) (*Parser, error) {
 	return px, nil
 }
 
-// Execute #
+// saveStep #
+func (dbs *DbScript) saveStep(st *Step) error {
+	st.Finished = time.Now()
+
+	if dbs.SaveStep != nil {
+		return dbs.SaveStep(st)
+	}
+
+	if dbs.SaveVers != nil {
+		if !st.Version.HasDbu() {
+			return fmt.Errorf("Parser.SaveVers: %s: %w", st.Version, ErrDbuRange)
+		}
+		return dbs.SaveVers(st.Version.Dbu())
+	}
+
+	return nil
+}
+
+// tokenVersion # X.Y of $dbu_start X.Y, $lastdbu = X.Y
+func tokenVersion(tk *Token) (int, Version, error) {
+	op, val := tk.FieldKeyVal()
+	if len(tk.Fields) == 1 {
+		op, val = TkEQ, tk.Fields[0].Key
+	}
+
+	v, err := ParseVersion(val)
+	return op, v, err
+}
+
+// Execute # in transactions of TxScope, rollback on error
 func (dbs *DbScript) Execute(px *Parser) (int, error) {
-	ndbu := 0
-	nupd := 0
-	xdbu := 0
+	if err := dbs.begin(); err != nil {
+		return 0, err
+	}
+
+	a, err := dbs.execute(px)
+	if err != nil {
+		dbs.rollback()
+		return a, err
+	}
+
+	return a, dbs.commit()
+}
+
+// resume # commands of the block done by an earlier run
+func (dbs *DbScript) resume(step *Step, block []*Token) (int, error) {
+	if dbs.LoadCheckpoint == nil {
+		return 0, nil
+	}
+
+	cp, err := dbs.LoadCheckpoint(step.Version)
+	if err != nil || cp == nil {
+		return 0, err
+	}
+
+	if cp.Checksum != checksumN(block, cp.Done) {
+		return 0, errors.New("Parser.Resume: " + step.Version.String() + ": commands done by an earlier run changed")
+	}
+
+	return cp.Done, nil
+}
+
+func (dbs *DbScript) execute(px *Parser) (int, error) {
 	cmdID := 0
 	a := 0
-	lastDBU := -dbs.Vinfo.Dbu
 
-	for _, tk := range px.Token {
+	if dbs.Vinfo.Vers.IsZero() {
+		dbs.Vinfo.Vers = DbuVersion(dbs.Vinfo.Dbu)
+	}
+
+	defer func() {
+		dbs.Vinfo.Dbu = dbs.Vinfo.Vers.Dbu()
+	}()
+
+	started := time.Now()
+	var last *Step
+	var step *Step
+	var block []*Token
+	skip := false
+	exit := false
+	// commands of the open block, done by an earlier run
+	pos := 0
+	done := 0
+
+	for ti, tk := range px.Token {
 		ok := false
+		reason := ""
 		debug("token:", tk.ID, Token2String(int(tk.ID)))
 
+		if skip && tk.ID != TkDbuEnd {
+			continue
+		}
+
+		first := 0
+		if step != nil && tk.ID != TkDbuEnd && pos < done {
+			if n := cmdCount(tk); pos+n <= done {
+				if dbs.plan != nil {
+					dbs.plan.add(PlanSkip, step.Version, tk.GetData(0), "done by an earlier run")
+				}
+				pos += n
+				continue
+			}
+			first = done - pos
+		}
+
 		switch tk.ID {
 		// APP_VERSION
 		case TkAppVersion:
@@ -104,54 +256,114 @@ func (dbs *DbScript) Execute(px *Parser) (int, error) {
 			cmdID = TkSet
 			ok = true
 
+		// DBU_START X.Y, block applied if X.Y is higher than the version
+		case TkDbuStart:
+			if !dbs.Blocks {
+				ok = true
+				break
+			}
+
+			_, v, err := tokenVersion(tk)
+			if err != nil {
+				return a, errors.New("Parser.DbuStart: " + err.Error())
+			}
+
+			if step != nil {
+				return a, errors.New("Parser.DbuStart: " + v.String() + " inside " + step.Version.String())
+			}
+
+			block = blockTokens(px.Token[ti+1:])
+			sum := checksum(block)
+
+			if v.Cmp(dbs.Vinfo.Vers) <= 0 {
+				skip = true
+				if dbs.plan != nil {
+					dbs.plan.add(PlanSkip, v, "$dbu_start "+v.String(), "applied, database at "+dbs.Vinfo.Vers.String())
+				}
+				if dbs.CheckStep != nil {
+					if err := dbs.CheckStep(&Step{Version: v, Script: px.FileName, Checksum: sum}); err != nil {
+						return a, err
+					}
+				}
+				continue
+			}
+
+			if err := dbs.stepTx(); err != nil {
+				return a, err
+			}
+
+			step = &Step{Version: v, Script: px.FileName, Checksum: sum, Started: time.Now()}
+			pos = 0
+			if done, err = dbs.resume(step, block); err != nil {
+				return a, err
+			}
+			continue
+
+		case TkDbuEnd:
+			if !dbs.Blocks {
+				ok = true
+				break
+			}
+
+			if skip {
+				skip = false
+				continue
+			}
+
+			if step == nil {
+				return a, errors.New("Parser.DbuEnd: without $dbu_start")
+			}
+
+			if dbs.plan != nil {
+				dbs.plan.add(PlanStep, step.Version, "", "")
+			} else if err := dbs.saveStep(step); err != nil {
+				return a, err
+			}
+
+			dbs.Vinfo.Vers = step.Version
+			step = nil
+			if err := dbs.stepTx(); err != nil {
+				return a, err
+			}
+			continue
+
 		// LASTDBU
 		case TkDbuLast:
-			_, val := tk.FieldKeyVal()
-			ss := strings.Split(val, ".")
-			if len(ss) != 2 {
+			_, v, err := tokenVersion(tk)
+			if err != nil {
 				return a, errors.New("Parser.LastDbu: bad value")
 			}
 
-			ndbu, _ = strconv.Atoi(ss[0])
-			nupd, _ = strconv.Atoi(ss[1])
-			xdbu = ndbu*100 + nupd
-
-			if xdbu > dbs.Vinfo.Dbu {
-				dbs.Vinfo.Dbu = xdbu
-				lastDBU = xdbu
-			} else if xdbu < dbs.Vinfo.Dbu {
+			switch v.Cmp(dbs.Vinfo.Vers) {
+			case 1:
+				dbs.Vinfo.Vers = v
+				last = &Step{Version: v, Script: px.FileName, Started: started}
+			case -1:
 				return a, errors.New("Parser.LastDbu: bad value")
 			}
 			continue
 
-		// DBU_VERSION
+		// DBU_VERSION, > and >= accept the previous update too
 		case TkDbuVersion:
-			op, val := tk.FieldKeyVal()
-			ss := strings.Split(val, ".")
-			if len(ss) != 2 {
+			op, v, err := tokenVersion(tk)
+			if err != nil {
 				return a, errors.New("Parser.Dbu: bad value")
 			}
-			ndbu, _ = strconv.Atoi(ss[0])
-			nupd, _ = strconv.Atoi(ss[1])
-			xdbu = ndbu*100 + nupd
+
+			c := dbs.Vinfo.Vers.Cmp(v)
 			switch op {
 			case TkEQ:
-				ok = dbs.Vinfo.Dbu == xdbu
+				ok = c == 0
+			case TkNE:
+				ok = c != 0
+			case TkLT:
+				ok = c < 0
+			case TkLE:
+				ok = c <= 0
 			case TkGT:
-				ok = (dbs.Vinfo.Dbu/100 == ndbu) && (dbs.Vinfo.Dbu%100 == nupd-1)
-				if !ok {
-					ok = (dbs.Vinfo.Dbu/100 == ndbu-1) && (nupd == 0)
-				}
+				ok = v.Follows(dbs.Vinfo.Vers)
 			case TkGE:
-				ok = dbs.Vinfo.Dbu == xdbu
-
-				if !ok {
-					ok = (dbs.Vinfo.Dbu/100 == ndbu) && (dbs.Vinfo.Dbu%100 == nupd-1)
-				}
-
-				if !ok {
-					ok = (dbs.Vinfo.Dbu/100 == ndbu-1) && (nupd == 0)
-				}
+				ok = c == 0 || v.Follows(dbs.Vinfo.Vers)
 			}
 
 			if !ok {
@@ -159,7 +371,6 @@ func (dbs *DbScript) Execute(px *Parser) (int, error) {
 			}
 
 			continue
-
 		case TkShow:
 			dbs.Vinfo.Show = true
 			continue
@@ -212,6 +423,12 @@ func (dbs *DbScript) Execute(px *Parser) (int, error) {
 				if neg {
 					ok = !ok
 				}
+
+				ss := []string{"$if"}
+				for _, f := range tk.Fields {
+					ss = append(ss, f.Key)
+				}
+				reason = strings.Join(ss, " ") + " is false"
 			default:
 				return a, errors.New("Parser.IF: bad operator")
 			}
@@ -249,16 +466,37 @@ func (dbs *DbScript) Execute(px *Parser) (int, error) {
 
 			if tk.ID == TkOneNotIf {
 				ok = !ok
+				reason = val + " exists"
+			} else {
+				reason = val + " does not exist"
 			}
 
 		case TkEcv:
-			if dbs.ExecEcv != nil {
+			execEcv := dbs.ExecEcv
+			if execEcv == nil && dbs.DB != nil {
+				execEcv = dbs.ImportEcv
+			}
+
+			if dbs.plan != nil {
+				lin := tk.Cmds2Data()
+				if lin.Count > 0 {
+					dbs.plan.add(PlanEcv, blk(step), *lin.Data[0], strconv.Itoa(lin.Count-1)+" lines")
+				}
+			} else if execEcv != nil {
 				lin := tk.Cmds2Data()
 
-				err := dbs.ExecEcv(lin)
+				err := execEcv(lin)
 				if err != nil {
 					return a, err
 				}
+
+				if err = dbs.checkpoint(step, block, pos+1); err != nil {
+					return a, err
+				}
+			}
+
+			if step != nil {
+				pos++
 			}
 			cmdID = TkNone
 			a += len(tk.Cmds[0]) + 2
@@ -267,10 +505,26 @@ func (dbs *DbScript) Execute(px *Parser) (int, error) {
 			ok = true
 		}
 
+		// partly done by an earlier run, the condition was true
+		if first > 0 {
+			ok = true
+		}
+
+		if !ok && dbs.plan != nil && reason != "" {
+			dbs.plan.add(PlanSkip, blk(step), tk.GetData(0), reason)
+		}
+
 		if ok {
-			for i := 0; i < len(tk.Cmds); i++ {
+			for i := first; i < len(tk.Cmds); i++ {
 				sq := tk.GetData(i)
-				end, err := dbs.ExecCmd(cmdID, i, sq)
+
+				var end bool
+				var err error
+				if dbs.plan != nil {
+					end, err = dbs.planCmd(cmdID, sq, blk(step))
+				} else {
+					end, err = dbs.ExecCmd(cmdID, i, sq)
+				}
 				cmdID = TkNone
 				if err != nil {
 					return a, err
@@ -279,23 +533,53 @@ func (dbs *DbScript) Execute(px *Parser) (int, error) {
 					ok = false
 					break
 				}
+
+				if err = dbs.checkpoint(step, block, pos+i+1); err != nil {
+					return a, err
+				}
 			}
 
 			if !ok {
+				exit = true
 				break
 			}
 		}
+
+		if step != nil {
+			pos += cmdCount(tk)
+		}
 		a++
 	}
 
-	if lastDBU > 0 {
-		err := dbs.SaveVers(lastDBU)
-		if err != nil {
+	if step != nil && !exit {
+		return a, errors.New("Parser.DbuStart: " + step.Version.String() + " without $dbu_end")
+	}
+
+	if last != nil {
+		if dbs.plan != nil {
+			dbs.plan.add(PlanStep, last.Version, "", "")
+		} else if err := dbs.saveStep(last); err != nil {
 			return a, err
 		}
-	} else {
-		dbs.Vinfo.Dbu = -lastDBU
 	}
 
 	return a, nil
 }
+
+// checkpoint # n commands of the open block done
+func (dbs *DbScript) checkpoint(step *Step, block []*Token, n int) error {
+	if step == nil || dbs.SaveCheckpoint == nil || dbs.plan != nil {
+		return nil
+	}
+
+	return dbs.SaveCheckpoint(&Checkpoint{step.Version, step.Script, n, checksumN(block, n)})
+}
+
+// blk # version of the open dbu block
+func blk(step *Step) Version {
+	if step == nil {
+		return Version{}
+	}
+
+	return step.Version
+}
@@ -11,12 +11,16 @@ package script
 // ----------------------------------------------------------------------------------
 // HISTORY
 // ----------------------------------------------------------------------------------
+// 2023.05.17 Blocks
+// 2023.05.16 Lines.Reader
+// 2023.05.13 FileName, $dbu_start and $dbu_end outside of other tokens
 // 2020.07.19 TkField abfragen
 // 2020.05.23 init
 // ----------------------------------------------------------------------------------
 
 import (
 	"errors"
+	"io"
 	"io/ioutil"
 	"strconv"
 	"strings"
@@ -24,7 +28,10 @@ import (
 
 // Parser #
 type Parser struct {
-	Token []*Token
+	Token    []*Token
+	FileName string
+	// Blocks # $dbu_start and $dbu_end outside of other tokens, commands like the others otherwise
+	Blocks bool
 }
 
 // Token #
@@ -62,6 +69,7 @@ func (x *Parser) LoadFile(fname string) error {
 		return err
 	}
 
+	x.FileName = fname
 	return x.load(&b)
 }
 
@@ -119,6 +127,13 @@ func (x *Parser) load(b *[]byte) error {
 				continue
 			}
 
+			if x.Blocks && (tk.ID == TkDbuStart || tk.ID == TkDbuEnd) {
+				if cTok != nil && cTok.ID == TkIf {
+					return errors.New("error line #" + strconv.Itoa(i+1) + " " + lk + " inside $if")
+				}
+				cTok = nil
+			}
+
 			if tk.ID == TkEcvStop {
 				debug("#decode: cTok.ENDECV")
 				cTok = nil
@@ -339,6 +354,17 @@ func (x *Token) FieldIE() (int, int, string) {
 	return op, typ, val
 }
 
+// Reader # lines separated by \n
+func (l *Lines) Reader() io.Reader {
+	var sb strings.Builder
+	for _, s := range l.Data {
+		sb.WriteString(*s)
+		sb.WriteByte('\n')
+	}
+
+	return strings.NewReader(sb.String())
+}
+
 // Get #------------- Lines --------
 func (l *Lines) Get(s *string) bool {
 	if l.Idx >= l.Count {
@@ -0,0 +1,180 @@
+package script
+
+// ----------------------------------------------------------------------------------
+// plan.go for Go's dbx.script package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.15 init, dry run of Execute
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// PlanKind #
+type PlanKind int
+
+const (
+	// PlanExec # statement that would be executed
+	PlanExec PlanKind = iota
+	// PlanSkip # block or command that would be skipped, see Reason
+	PlanSkip
+	// PlanEcv # ecv block that would be imported
+	PlanEcv
+	// PlanStep # dbu step that would be recorded
+	PlanStep
+	// PlanExit # $exit, the rest of the script would not run
+	PlanExit
+)
+
+// PlanEntry #
+type PlanEntry struct {
+	Kind PlanKind
+	// Version # of the dbu block or step, zero outside of blocks
+	Version Version
+	SQL     string
+	Reason  string
+}
+
+// Plan # what Execute would do, the conditions are evaluated against
+// the database as it is, objects created by the script itself do not exist yet
+type Plan struct {
+	Entries []PlanEntry
+	// Vers # version after the script
+	Vers Version
+}
+
+func (p *Plan) add(kind PlanKind, v Version, sq string, reason string) {
+	p.Entries = append(p.Entries, PlanEntry{kind, v, sq, reason})
+}
+
+// Statements # sql of the PlanExec entries
+func (p *Plan) Statements() []string {
+	var ss []string
+	for _, e := range p.Entries {
+		if e.Kind == PlanExec {
+			ss = append(ss, e.SQL)
+		}
+	}
+
+	return ss
+}
+
+// WriteTo # statements terminated by ;, everything else as -- comment
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	blk := Version{}
+	for _, e := range p.Entries {
+		if e.Version != blk && !e.Version.IsZero() && e.Kind != PlanStep {
+			bw.WriteString("-- dbu " + e.Version.String() + "\n")
+		}
+		blk = e.Version
+
+		switch e.Kind {
+		case PlanExec:
+			bw.WriteString(strings.TrimSuffix(e.SQL, ";") + ";\n")
+		case PlanSkip:
+			bw.WriteString("-- skip " + firstLine(e.SQL) + ": " + e.Reason + "\n")
+		case PlanEcv:
+			bw.WriteString("-- ecv " + firstLine(e.SQL) + ": " + e.Reason + "\n")
+		case PlanStep:
+			bw.WriteString("-- record dbu " + e.Version.String() + "\n")
+		case PlanExit:
+			bw.WriteString("-- exit\n")
+		}
+	}
+	bw.WriteString("-- dbu after the script " + p.Vers.String() + "\n")
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// String #
+func (p *Plan) String() string {
+	var sb strings.Builder
+	p.WriteTo(&sb)
+	return sb.String()
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " .."
+	}
+
+	return s
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// Plan # Execute without ExecCmd, ExecEcv and SaveStep,
+// Translate gives the statements of a command, if set
+func (dbs *DbScript) Plan(px *Parser) (*Plan, error) {
+	return dbs.planWith(px, dbs.Execute)
+}
+
+func (dbs *DbScript) planWith(px *Parser, execute func(px *Parser) (int, error)) (*Plan, error) {
+	p := &Plan{}
+	vinfo := dbs.Vinfo
+
+	dbs.plan = p
+	defer func() {
+		dbs.plan = nil
+		dbs.Vinfo = vinfo
+	}()
+
+	_, err := execute(px)
+	p.Vers = dbs.Vinfo.Vers
+
+	return p, err
+}
+
+// planCmd # ExecCmd of the plan
+func (dbs *DbScript) planCmd(cmdID int, cmd string, blk Version) (bool, error) {
+	w := strings.Fields(strings.ToLower(cmd))
+	if cmdID == TkExit || (len(w) > 0 && w[0] == "$exit") {
+		dbs.plan.add(PlanExit, blk, strings.TrimSpace(strings.SplitN(cmd, "\n", 2)[0]), "")
+		return true, nil
+	}
+
+	if dbs.Translate == nil {
+		if len(w) > 0 && w[0][0] != '$' {
+			dbs.plan.add(PlanExec, blk, cmd, "")
+		}
+		return false, nil
+	}
+
+	sqs, err := dbs.Translate(cmd)
+	if err != nil {
+		return false, err
+	}
+
+	if len(sqs) == 0 && len(w) > 1 && w[0] == "$drop" {
+		dbs.plan.add(PlanSkip, blk, cmd, "does not exist")
+	}
+
+	for _, sq := range sqs {
+		dbs.plan.add(PlanExec, blk, sq, "")
+	}
+
+	return false, nil
+}
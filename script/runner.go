@@ -0,0 +1,704 @@
+package script
+
+// ----------------------------------------------------------------------------------
+// runner.go for Go's dbx.script package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 ddl applied at commit committed with its checkpoint, ErrTxScript
+// 2023.05.17 version preset by the caller while VersTable is empty, DBU NULL beyond 99 updates
+// 2023.05.16 transactions, CheckTable with checkpoints if ddl is not transactional
+// 2023.05.15 Plan without changes of VersTable
+// 2023.05.14 drift of applied dbu blocks, DriftMode
+// 2023.05.13 VersTable as ledger of the applied steps, Versions
+// 2023.05.12 init, DbScript bound to dbx.DB, version table
+// ----------------------------------------------------------------------------------
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/waldurbas/dbx"
+)
+
+// VersTable # ledger of the applied dbu steps, created by the Runner
+var VersTable = "DBX_VERSIONS"
+
+// versCols # columns of VersTable, times as YYYY-MM-DD HH:MM:SS text,
+// DBU is X*100+Y for readers of the old notation, NULL beyond 99 updates
+var versCols = []struct {
+	name string
+	typ  string
+}{
+	{"VERSION", "varchar(20) not null"},
+	{"DBU", "integer"},
+	{"APPLIED", "varchar(19)"},
+	{"MAJOR", "integer"},
+	{"MINOR", "integer"},
+	{"SCRIPT", "varchar(255)"},
+	{"CHECKSUM", "varchar(64)"},
+	{"STARTED", "varchar(19)"},
+	{"DURATION_MS", "integer"},
+	{"USER_NAME", "varchar(64)"},
+	{"HOST_NAME", "varchar(64)"},
+}
+
+const versTime = "2006-01-02 15:04:05"
+
+// CheckTable # checkpoints of unfinished dbu blocks, if the ddl of the database
+// cannot be rolled back, created by the Runner
+var CheckTable = "DBX_CHECKPOINT"
+
+// ErrTxScript # TxScript on a database applying ddl at commit,
+// blocks committed with their ddl cannot be rolled back
+var ErrTxScript = errors.New("script: TxScript needs ddl rolled back with the script, the database applies it at commit, use TxStep")
+
+// ErrDrift # applied dbu block changed in the script
+var ErrDrift = errors.New("script: applied dbu block changed")
+
+// DriftMode # handling of applied dbu blocks with another checksum than recorded
+type DriftMode int
+
+const (
+	// DriftRefuse # stop with ErrDrift
+	DriftRefuse DriftMode = iota
+	// DriftWarn # warning on Out, go on
+	DriftWarn
+	// DriftRepair # record the checksum of the script, go on
+	DriftRepair
+)
+
+// Drift # applied dbu block with another checksum than recorded
+type Drift struct {
+	Version  Version
+	Recorded string
+	Checksum string
+}
+
+// Runner # DbScript with existence checks, commands and versions on a dbx.DB
+type Runner struct {
+	*DbScript
+	// Out # output of $echo and warnings, default os.Stdout
+	Out io.Writer
+	// Drift # handling of changed blocks, default DriftRefuse
+	Drift DriftMode
+	// Drifts # changed blocks found by the last Execute
+	Drifts []Drift
+
+	db        *dbx.DB
+	tx        *dbx.Tx
+	ddl       bool
+	applied   map[Version]Step
+	checks    map[Version]Checkpoint
+	hasChecks bool
+}
+
+// NewRunner #
+func NewRunner(db *dbx.DB) *Runner {
+	r := &Runner{DbScript: NewScript(), Out: os.Stdout, db: db}
+
+	dbs := r.DbScript
+	dbs.DB = db
+	dbs.Blocks = true
+	dbs.ExecCmd = r.execCmd
+	dbs.SaveStep = r.saveStep
+	dbs.CheckStep = r.checkStep
+	dbs.Translate = r.Translate
+	dbs.ExecEcv = r.importEcv
+	dbs.LoadCheckpoint = r.loadCheckpoint
+	dbs.ExistTable = r.existFunc(dbx.ObjTable)
+	dbs.ExistTableCol = r.existFunc(dbx.ObjTableCol)
+	dbs.ExistIndex = r.existFunc(dbx.ObjIndex)
+	dbs.ExistTrigger = r.existFunc(dbx.ObjTrigger)
+	dbs.ExistProc = r.existFunc(dbx.ObjProc)
+	dbs.ExistFunc = r.existFunc(dbx.ObjFunc)
+	dbs.ExistDomain = r.existFunc(dbx.ObjDomain)
+	dbs.ExistException = r.existFunc(dbx.ObjException)
+
+	return r
+}
+
+// exist # inside the open transaction
+func (r *Runner) exist(obj dbx.Object, sName string) (bool, error) {
+	if r.tx != nil {
+		ok := r.tx.Exist(obj, sName)
+		return ok, r.tx.Err
+	}
+
+	ok := r.db.Exist(obj, sName)
+	return ok, r.db.Err
+}
+
+func (r *Runner) existFunc(obj dbx.Object) func(sName string) bool {
+	return func(sName string) bool {
+		ok, _ := r.exist(obj, sName)
+		return ok
+	}
+}
+
+// execute # inside the open transaction
+func (r *Runner) execute(sq string, args ...interface{}) error {
+	if r.tx == nil {
+		return r.db.Execute(sq, args...)
+	}
+
+	if err := r.tx.Execute(sq, args...); err != nil {
+		return err
+	}
+
+	r.ddl = r.db.Dialect.DDLAtCommit() && isDDL(sq)
+	return nil
+}
+
+// importEcv # default of ExecEcv, inside the open transaction
+func (r *Runner) importEcv(a *Lines) error {
+	var err error
+	if r.tx == nil {
+		_, err = r.db.ImportEcv(a.Reader(), r.ecvOptions())
+	} else {
+		_, err = r.tx.ImportEcv(a.Reader(), r.ecvOptions())
+	}
+
+	return err
+}
+
+// ddlWords # first words of ddl statements
+var ddlWords = map[string]bool{"create": true, "alter": true, "drop": true, "recreate": true,
+	"comment": true, "grant": true, "revoke": true, "declare": true}
+
+func isDDL(sq string) bool {
+	w := strings.Fields(sq)
+	return len(w) > 0 && ddlWords[strings.ToLower(w[0])]
+}
+
+func (r *Runner) begin() error {
+	tx, err := r.db.Begin()
+	r.tx = tx
+	return err
+}
+
+func (r *Runner) commit() error {
+	tx := r.tx
+	r.tx, r.ddl = nil, false
+	return tx.Commit()
+}
+
+func (r *Runner) rollback() error {
+	tx := r.tx
+	r.tx, r.ddl = nil, false
+	return tx.Rollback()
+}
+
+// Run # load and execute fileName
+func (r *Runner) Run(fileName string) (int, error) {
+	px, err := r.LoadFile(fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.Execute(px)
+}
+
+// Plan # of Execute, VersTable is read only
+func (r *Runner) Plan(px *Parser) (*Plan, error) {
+	return r.planWith(px, r.Execute)
+}
+
+// PlanFile # load fileName and Plan
+func (r *Runner) PlanFile(fileName string) (*Plan, error) {
+	px, err := r.LoadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Plan(px)
+}
+
+// Execute # with the version of VersTable, the table is created if missing;
+// while VersTable is empty, Vinfo.Vers or Vinfo.Dbu preset by the caller is the version
+func (r *Runner) Execute(px *Parser) (int, error) {
+	if r.TxScope == TxScript && r.db.Dialect.TxDDL() && r.db.Dialect.DDLAtCommit() && r.plan == nil {
+		return 0, ErrTxScript
+	}
+
+	steps, err := r.Versions()
+	if err != nil {
+		return 0, err
+	}
+
+	r.Drifts = nil
+	r.applied = map[Version]Step{}
+	if r.Vinfo.Vers.IsZero() {
+		r.Vinfo.Vers = DbuVersion(r.Vinfo.Dbu)
+	}
+	for _, st := range steps {
+		r.applied[st.Version] = st
+		r.Vinfo.Vers = st.Version
+	}
+
+	r.Vinfo.Dbu = r.Vinfo.Vers.Dbu()
+
+	if err = r.loadChecks(); err != nil {
+		return 0, err
+	}
+
+	// rollback where the ddl allows it, checkpoints otherwise;
+	// ddl applied at commit is committed with its checkpoint in dbu blocks
+	r.Begin, r.Commit, r.Rollback, r.SaveCheckpoint = nil, nil, nil, nil
+	switch {
+	case r.TxScope == TxNone:
+	case r.db.Dialect.TxDDL():
+		r.Begin, r.Commit, r.Rollback = r.begin, r.commit, r.rollback
+		if r.db.Dialect.DDLAtCommit() && r.plan == nil {
+			if err = r.createCheckTable(); err != nil {
+				return 0, err
+			}
+			r.SaveCheckpoint = r.saveCheckpoint
+		}
+	default:
+		r.SaveCheckpoint = r.saveCheckpoint
+	}
+
+	return r.DbScript.Execute(px)
+}
+
+// loadChecks # checkpoints of CheckTable
+func (r *Runner) loadChecks() error {
+	r.checks = map[Version]Checkpoint{}
+	r.hasChecks = r.db.ExistTable(CheckTable)
+	if !r.hasChecks {
+		return nil
+	}
+
+	rows, err := r.db.Query("select VERSION,SCRIPT,DONE,CHECKSUM from " + CheckTable)
+	if err != nil {
+		return fmt.Errorf("Runner.Checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var vers string
+		var scr, sum sql.NullString
+		var done int
+
+		if err = rows.Scan(&vers, &scr, &done, &sum); err != nil {
+			return fmt.Errorf("Runner.Checkpoints: %w", err)
+		}
+
+		cp := Checkpoint{Script: scr.String, Done: done, Checksum: sum.String}
+		if cp.Version, err = ParseVersion(vers); err != nil {
+			return fmt.Errorf("Runner.Checkpoints: %w", err)
+		}
+		r.checks[cp.Version] = cp
+	}
+
+	return rows.Err()
+}
+
+// Checkpoints # unfinished dbu blocks
+func (r *Runner) Checkpoints() ([]Checkpoint, error) {
+	if err := r.loadChecks(); err != nil {
+		return nil, err
+	}
+
+	var cps []Checkpoint
+	for _, cp := range r.checks {
+		cps = append(cps, cp)
+	}
+
+	sort.Slice(cps, func(i, j int) bool { return cps[i].Version.Cmp(cps[j].Version) < 0 })
+	return cps, nil
+}
+
+// loadCheckpoint # default of LoadCheckpoint
+func (r *Runner) loadCheckpoint(v Version) (*Checkpoint, error) {
+	if cp, ok := r.checks[v]; ok {
+		return &cp, nil
+	}
+
+	return nil, nil
+}
+
+// createCheckTable # outside of transactions, as it is ddl
+func (r *Runner) createCheckTable() error {
+	if r.hasChecks {
+		return nil
+	}
+
+	err := r.db.Execute("create table " + CheckTable + " (VERSION varchar(20) not null, SCRIPT varchar(255), " +
+		"DONE integer not null, CHECKSUM varchar(64), UPDATED varchar(19))")
+	r.hasChecks = err == nil
+
+	return err
+}
+
+// saveCheckpoint # default of SaveCheckpoint, without transaction or with ddl applied at commit
+func (r *Runner) saveCheckpoint(cp *Checkpoint) error {
+	if err := r.createCheckTable(); err != nil {
+		return err
+	}
+
+	scr := cp.Script
+	if scr != "" {
+		scr = filepath.Base(scr)
+	}
+
+	exec := r.db.Execute
+	if r.tx != nil {
+		exec = r.tx.Execute
+	}
+
+	err := exec("delete from "+CheckTable+" where VERSION=:vers", sql.Named("vers", cp.Version.String()))
+	if err == nil {
+		err = exec("insert into "+CheckTable+" (VERSION,SCRIPT,DONE,CHECKSUM,UPDATED) values (:vers,:script,:done,:checksum,:updated)",
+			sql.Named("vers", cp.Version.String()),
+			sql.Named("script", scr),
+			sql.Named("done", cp.Done),
+			sql.Named("checksum", cp.Checksum),
+			sql.Named("updated", time.Now().Format(versTime)))
+	}
+
+	// ddl applied at commit is committed with its checkpoint, before the next command
+	if err == nil && r.ddl {
+		if err = r.commit(); err == nil {
+			err = r.begin()
+		}
+	}
+
+	return err
+}
+
+// LoadVers # highest version of VersTable
+func (r *Runner) LoadVers() (Version, error) {
+	steps, err := r.Versions()
+	if err != nil || len(steps) == 0 {
+		return Version{}, err
+	}
+
+	return steps[len(steps)-1].Version, nil
+}
+
+// Versions # applied steps of VersTable ordered by version
+func (r *Runner) Versions() ([]Step, error) {
+	if r.plan == nil {
+		if err := r.createVersTable(); err != nil {
+			return nil, err
+		}
+	} else if !r.db.ExistTable(VersTable) {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query("select VERSION,MAJOR,MINOR,SCRIPT,CHECKSUM,STARTED,APPLIED,USER_NAME,HOST_NAME from " + VersTable)
+	if err != nil {
+		return nil, fmt.Errorf("Runner.Versions: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []Step
+	for rows.Next() {
+		var vers string
+		var major, minor sql.NullInt64
+		var scr, sum, started, applied, usr, host sql.NullString
+
+		if err = rows.Scan(&vers, &major, &minor, &scr, &sum, &started, &applied, &usr, &host); err != nil {
+			return nil, fmt.Errorf("Runner.Versions: %w", err)
+		}
+
+		st := Step{Script: scr.String, Checksum: sum.String, User: usr.String, Host: host.String}
+		if major.Valid && minor.Valid {
+			st.Version = Version{int(major.Int64), int(minor.Int64)}
+		} else if st.Version, err = ParseVersion(vers); err != nil {
+			return nil, fmt.Errorf("Runner.Versions: %w", err)
+		}
+
+		st.Finished, _ = time.ParseInLocation(versTime, applied.String, time.Local)
+		st.Started, _ = time.ParseInLocation(versTime, started.String, time.Local)
+		if !started.Valid {
+			st.Started = st.Finished
+		}
+
+		steps = append(steps, st)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Runner.Versions: %w", err)
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		if c := steps[i].Version.Cmp(steps[j].Version); c != 0 {
+			return c < 0
+		}
+		return steps[i].Finished.Before(steps[j].Finished)
+	})
+
+	return steps, nil
+}
+
+// createVersTable # missing columns of an older table are added
+func (r *Runner) createVersTable() error {
+	if !r.db.ExistTable(VersTable) {
+		if r.db.Err != nil && r.db.Err != dbx.ErrNotSupported {
+			return r.db.Err
+		}
+
+		var ss []string
+		for _, c := range versCols {
+			ss = append(ss, c.name+" "+c.typ)
+		}
+
+		return r.db.Execute("create table " + VersTable + " (" + strings.Join(ss, ", ") + ")")
+	}
+
+	if r.db.Dialect.Exist(dbx.ObjTableCol) == "" {
+		return nil
+	}
+
+	for _, c := range versCols {
+		if r.db.ExistTableCol(VersTable + "." + c.name) {
+			continue
+		}
+
+		if r.db.Err != nil {
+			return r.db.Err
+		}
+
+		if err := r.db.Execute("alter table " + VersTable + " add " + c.name + " " + strings.TrimSuffix(c.typ, " not null")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveStep # default of SaveStep, user of the connection or of the os
+func (r *Runner) saveStep(st *Step) error {
+	st.User = r.db.Cfg.User
+	if st.User == "" {
+		if u, err := user.Current(); err == nil {
+			st.User = u.Username
+		}
+	}
+	st.Host, _ = os.Hostname()
+
+	scr := st.Script
+	if scr != "" {
+		scr = filepath.Base(scr)
+	}
+
+	if r.hasChecks {
+		err := r.execute("delete from "+CheckTable+" where VERSION=:vers", sql.Named("vers", st.Version.String()))
+		if err != nil {
+			return err
+		}
+	}
+
+	var dbu interface{}
+	if st.Version.HasDbu() {
+		dbu = st.Version.Dbu()
+	}
+
+	return r.execute("insert into "+VersTable+
+		" (VERSION,DBU,APPLIED,MAJOR,MINOR,SCRIPT,CHECKSUM,STARTED,DURATION_MS,USER_NAME,HOST_NAME)"+
+		" values (:vers,:dbu,:applied,:major,:minor,:script,:checksum,:started,:duration,:usr,:host)",
+		sql.Named("vers", st.Version.String()),
+		sql.Named("dbu", dbu),
+		sql.Named("applied", st.Finished.Format(versTime)),
+		sql.Named("major", st.Version.Major),
+		sql.Named("minor", st.Version.Minor),
+		sql.Named("script", scr),
+		sql.Named("checksum", st.Checksum),
+		sql.Named("started", st.Started.Format(versTime)),
+		sql.Named("duration", st.Duration().Milliseconds()),
+		sql.Named("usr", st.User),
+		sql.Named("host", st.Host))
+}
+
+// checkStep # default of CheckStep, a step recorded without checksum takes the one of the script
+func (r *Runner) checkStep(st *Step) error {
+	rec, ok := r.applied[st.Version]
+	if !ok || rec.Checksum == st.Checksum {
+		return nil
+	}
+
+	if rec.Checksum == "" {
+		return r.setChecksum(st)
+	}
+
+	r.Drifts = append(r.Drifts, Drift{st.Version, rec.Checksum, st.Checksum})
+
+	switch r.Drift {
+	case DriftWarn:
+		fmt.Fprintf(r.Out, "warning: dbu %s changed since it was applied\n", st.Version)
+		return nil
+	case DriftRepair:
+		return r.setChecksum(st)
+	}
+
+	return fmt.Errorf("%w: %s", ErrDrift, st.Version)
+}
+
+// setChecksum # of the recorded step, not in a plan
+func (r *Runner) setChecksum(st *Step) error {
+	if r.plan != nil {
+		return nil
+	}
+
+	return r.execute("update "+VersTable+" set CHECKSUM=:checksum where VERSION=:vers",
+		sql.Named("checksum", st.Checksum),
+		sql.Named("vers", st.Version.String()))
+}
+
+// execCmd # default of ExecCmd
+func (r *Runner) execCmd(cmdID int, ix int, cmd string) (bool, error) {
+	ss := strings.Fields(cmd)
+	if len(ss) == 0 {
+		return false, nil
+	}
+
+	switch strings.ToLower(ss[0]) {
+	case "$exit":
+		return true, nil
+	case "$echo":
+		fmt.Fprintln(r.Out, strings.TrimSpace(cmd[len(ss[0]):]))
+		return false, nil
+	}
+
+	sqs, err := r.Translate(cmd)
+	if err != nil {
+		return false, err
+	}
+
+	for _, sq := range sqs {
+		if err = r.execute(sq); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// objects # keywords of the script for the database objects
+var objects = map[string]dbx.Object{
+	"table":     dbx.ObjTable,
+	"field":     dbx.ObjTableCol,
+	"column":    dbx.ObjTableCol,
+	"index":     dbx.ObjIndex,
+	"trigger":   dbx.ObjTrigger,
+	"procedure": dbx.ObjProc,
+	"function":  dbx.ObjFunc,
+	"domain":    dbx.ObjDomain,
+	"exception": dbx.ObjException,
+}
+
+// Translate # statements of a script command:
+// add field T.C type -> alter table T add C type,
+// $drop obj name -> drop of the dialect if name exists,
+// recreate obj name .. -> drop if name exists and create obj name ..,
+// other $ commands -> none, sql as is
+func (r *Runner) Translate(cmd string) ([]string, error) {
+	ss := strings.Fields(cmd)
+	if len(ss) == 0 {
+		return nil, nil
+	}
+
+	w0 := strings.ToLower(ss[0])
+	switch {
+	case w0 == "$drop":
+		if len(ss) < 3 {
+			return nil, errors.New("Runner: bad command: " + cmd)
+		}
+		return r.drop(ss[1], ss[2])
+
+	case w0[0] == '$':
+		return nil, nil
+
+	case w0 == "add" && len(ss) > 3 && objects[strings.ToLower(ss[1])] == dbx.ObjTableCol:
+		tbl, col := dbx.SplitName(dbx.ObjTableCol, ss[2])
+		if tbl == "" {
+			return nil, errors.New("Runner: expected TABLE.FIELD: " + cmd)
+		}
+		return []string{"alter table " + tbl + " add " + col + " " + strings.Join(ss[3:], " ")}, nil
+
+	case w0 == "recreate" && len(ss) > 2:
+		return r.recreate(ss, cmd)
+	}
+
+	return []string{cmd}, nil
+}
+
+// drop # obj name, nothing if it does not exist
+func (r *Runner) drop(obj string, name string) ([]string, error) {
+	o, ok := objects[strings.ToLower(obj)]
+	if !ok {
+		return nil, errors.New("Runner: $drop: bad object " + obj)
+	}
+
+	if o == dbx.ObjTable && strings.Contains(name, ".") {
+		o = dbx.ObjTableCol
+	}
+
+	ok, err := r.exist(o, name)
+	if !ok {
+		if err != nil && err != dbx.ErrNotSupported {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	sq := r.db.Dialect.Drop(o, name)
+	if sq == "" {
+		return nil, dbx.ErrNotSupported
+	}
+
+	return []string{sq}, nil
+}
+
+// recreate # drop and create, views and others without existence check as is
+func (r *Runner) recreate(ss []string, cmd string) ([]string, error) {
+	o, ok := objects[strings.ToLower(ss[1])]
+	if !ok || o == dbx.ObjTableCol || o == dbx.ObjIndex {
+		return []string{cmd}, nil
+	}
+
+	name := ss[2]
+	if i := strings.Index(name, "("); i >= 0 {
+		name = name[:i]
+	}
+
+	// trigger TR for T, trigger TR .. on T
+	if o == dbx.ObjTrigger {
+		tbl := ""
+		for i := 3; i < len(ss)-1 && tbl == ""; i++ {
+			if w := strings.ToLower(ss[i]); w == "for" || w == "on" {
+				tbl = ss[i+1]
+			}
+		}
+
+		if tbl == "" {
+			return []string{cmd}, nil
+		}
+		name = tbl + "." + name
+	}
+
+	sqs, err := r.drop(ss[1], name)
+	if err != nil {
+		return nil, err
+	}
+
+	i := strings.Index(strings.ToLower(cmd), "recreate")
+	return append(sqs, cmd[:i]+"create"+cmd[i+8:]), nil
+}
@@ -0,0 +1,89 @@
+package script
+
+// ----------------------------------------------------------------------------------
+// tx.go for Go's dbx.script package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 ddl applied at commit
+// 2023.05.16 init, transaction scope, checkpoints of dbu blocks
+// ----------------------------------------------------------------------------------
+
+// TxScope # transactions of Execute; on databases applying ddl at commit,
+// ddl in dbu blocks is committed with its checkpoint before the next command,
+// a failed block resumes after it, TxScript is refused there
+type TxScope int
+
+const (
+	// TxNone # no transactions, every command on its own
+	TxNone TxScope = iota
+	// TxStep # a transaction per dbu block, commands between the blocks in one of their own
+	TxStep
+	// TxScript # one transaction for the whole script
+	TxScript
+)
+
+// Checkpoint # progress of a dbu block, Done commands with checksum of them
+type Checkpoint struct {
+	Version  Version
+	Script   string
+	Done     int
+	Checksum string
+}
+
+// useTx # Begin, Commit and Rollback for TxScope
+func (dbs *DbScript) useTx() bool {
+	return dbs.TxScope != TxNone && dbs.Begin != nil && dbs.plan == nil
+}
+
+func (dbs *DbScript) begin() error {
+	if !dbs.useTx() {
+		return nil
+	}
+
+	if err := dbs.Begin(); err != nil {
+		return err
+	}
+
+	dbs.inTx = true
+	return nil
+}
+
+func (dbs *DbScript) commit() error {
+	if !dbs.inTx {
+		return nil
+	}
+
+	dbs.inTx = false
+	return dbs.Commit()
+}
+
+func (dbs *DbScript) rollback() {
+	if !dbs.inTx {
+		return
+	}
+
+	dbs.inTx = false
+	if dbs.Rollback != nil {
+		dbs.Rollback()
+	}
+}
+
+// stepTx # commit point at the begin and end of a dbu block
+func (dbs *DbScript) stepTx() error {
+	if dbs.TxScope != TxStep || !dbs.inTx {
+		return nil
+	}
+
+	if err := dbs.commit(); err != nil {
+		return err
+	}
+
+	return dbs.begin()
+}
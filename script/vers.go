@@ -0,0 +1,127 @@
+package script
+
+// ----------------------------------------------------------------------------------
+// vers.go for Go's dbx.script package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 ErrDbuRange, Dbu 0 for more than 99 updates
+// 2023.05.13 init, Version without the 99 updates limit of Dbu, Step
+// ----------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version # dbu X.Y, X dbu number, Y update number
+type Version struct {
+	Major int
+	Minor int
+}
+
+// ParseVersion # X.Y
+func ParseVersion(s string) (Version, error) {
+	ss := strings.Split(strings.TrimSpace(s), ".")
+	if len(ss) != 2 {
+		return Version{}, errors.New("bad version: " + s)
+	}
+
+	x, err := strconv.Atoi(ss[0])
+	if err != nil || x < 0 {
+		return Version{}, errors.New("bad version: " + s)
+	}
+
+	y, err := strconv.Atoi(ss[1])
+	if err != nil || y < 0 {
+		return Version{}, errors.New("bad version: " + s)
+	}
+
+	return Version{x, y}, nil
+}
+
+// DbuVersion # Version of the X*100+Y notation of VersInfo.Dbu
+func DbuVersion(dbu int) Version {
+	return Version{dbu / 100, dbu % 100}
+}
+
+// ErrDbuRange # version with more than 99 updates, not expressible as X*100+Y
+var ErrDbuRange = errors.New("version beyond the X*100+Y notation of Dbu")
+
+// Dbu # X*100+Y, 0 for more than 99 updates, see HasDbu
+func (v Version) Dbu() int {
+	if !v.HasDbu() {
+		return 0
+	}
+
+	return v.Major*100 + v.Minor
+}
+
+// HasDbu # v fits into X*100+Y
+func (v Version) HasDbu() bool {
+	return v.Minor <= 99
+}
+
+// String # X.YY
+func (v Version) String() string {
+	y := strconv.Itoa(v.Minor)
+	if v.Minor < 10 {
+		y = "0" + y
+	}
+
+	return strconv.Itoa(v.Major) + "." + y
+}
+
+// IsZero #
+func (v Version) IsZero() bool {
+	return v.Major == 0 && v.Minor == 0
+}
+
+// Cmp # -1, 0, +1 if v is lower, equal, higher than o
+func (v Version) Cmp(o Version) int {
+	switch {
+	case v.Major < o.Major:
+		return -1
+	case v.Major > o.Major:
+		return 1
+	case v.Minor < o.Minor:
+		return -1
+	case v.Minor > o.Minor:
+		return 1
+	}
+
+	return 0
+}
+
+// Follows # v is the next update of o, X.Y after X.Y-1 or X.0 after X-1.*
+func (v Version) Follows(o Version) bool {
+	if v.Major == o.Major {
+		return v.Minor == o.Minor+1
+	}
+
+	return v.Major == o.Major+1 && v.Minor == 0
+}
+
+// Step # applied dbu step, a $dbu_start block or a script with $lastdbu
+type Step struct {
+	Version  Version
+	Script   string
+	Checksum string
+	Started  time.Time
+	Finished time.Time
+	User     string
+	Host     string
+}
+
+// Duration #
+func (s *Step) Duration() time.Duration {
+	return s.Finished.Sub(s.Started)
+}
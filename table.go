@@ -0,0 +1,240 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// table.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.06 init, two-pass auto-width table
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// TableOptions # zero value: 1000 rows measured, 40 cells wide, no border, footer
+type TableOptions struct {
+	// MaxRows # rows buffered to measure the widths, later rows are cut to these widths
+	MaxRows int
+	// MaxWidth # display width of a column, longer values end with …
+	MaxWidth int
+	// Border # box drawing lines
+	Border bool
+	// NoFooter # without (n rows)
+	NoFooter bool
+}
+
+func (o *TableOptions) withDefaults() TableOptions {
+	c := TableOptions{}
+	if o != nil {
+		c = *o
+	}
+
+	if c.MaxRows <= 0 {
+		c.MaxRows = 1000
+	}
+
+	if c.MaxWidth <= 0 {
+		c.MaxWidth = 40
+	}
+
+	return c
+}
+
+var tableCtrl = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// tableCell # display text of f on one line
+func (f *SqxField) tableCell() string {
+	if f.IsNull() {
+		return "NULL"
+	}
+
+	if f.Typ == "BLOB" && !utf8.Valid(f.Value) {
+		return "[" + strconv.Itoa(len(f.Value)) + " bytes]"
+	}
+
+	return tableCtrl.Replace(f.AsString())
+}
+
+type tableWriter struct {
+	w      *bufio.Writer
+	o      TableOptions
+	widths []int
+	right  []bool
+}
+
+func (t *tableWriter) cell(s string, i int) string {
+	wd := t.widths[i]
+	if runewidth.StringWidth(s) > wd {
+		s = runewidth.Truncate(s, wd, "…")
+	}
+
+	if t.right[i] {
+		return runewidth.FillLeft(s, wd)
+	}
+
+	return runewidth.FillRight(s, wd)
+}
+
+func (t *tableWriter) line(cells []string) {
+	if t.o.Border {
+		t.w.WriteString("│ ")
+	}
+
+	for i, s := range cells {
+		if i > 0 {
+			if t.o.Border {
+				t.w.WriteString(" │ ")
+			} else {
+				t.w.WriteString(" ")
+			}
+		}
+
+		s = t.cell(s, i)
+		if !t.o.Border && i == len(cells)-1 {
+			s = strings.TrimRight(s, " ")
+		}
+		t.w.WriteString(s)
+	}
+
+	if t.o.Border {
+		t.w.WriteString(" │")
+	}
+	t.w.WriteByte('\n')
+}
+
+// rule # left, cross and right of a border line, plain: dashes
+func (t *tableWriter) rule(l, x, r string) {
+	if !t.o.Border {
+		for i, wd := range t.widths {
+			if i > 0 {
+				t.w.WriteByte(' ')
+			}
+			t.w.WriteString(strings.Repeat("-", wd))
+		}
+		t.w.WriteByte('\n')
+		return
+	}
+
+	t.w.WriteString(l)
+	for i, wd := range t.widths {
+		if i > 0 {
+			t.w.WriteString(x)
+		}
+		t.w.WriteString(strings.Repeat("─", wd+2))
+	}
+	t.w.WriteString(r + "\n")
+}
+
+func (q *SQLX) tableRow() []string {
+	cells := make([]string, len(q.Fields))
+	for i := range q.Fields {
+		cells[i] = q.Fields[i].tableCell()
+	}
+
+	return cells
+}
+
+// WriteTable # fetch all rows as aligned table into w, the column widths
+// are measured on the first MaxRows rows
+func (q *SQLX) WriteTable(w io.Writer, opts *TableOptions) error {
+	t := &tableWriter{w: bufio.NewWriter(w), o: opts.withDefaults()}
+
+	title := make([]string, len(q.Fields))
+	t.widths = make([]int, len(q.Fields))
+	t.right = make([]bool, len(q.Fields))
+	for i := range q.Fields {
+		f := &q.Fields[i]
+		title[i] = f.Name
+		t.widths[i] = runewidth.StringWidth(f.Name)
+		t.right[i] = isNumeric(f.Typ)
+	}
+
+	// first pass: measure
+	var rows [][]string
+	more := false
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		cells := q.tableRow()
+		for i, s := range cells {
+			if n := runewidth.StringWidth(s); n > t.widths[i] {
+				t.widths[i] = n
+			}
+		}
+
+		rows = append(rows, cells)
+		if len(rows) >= t.o.MaxRows {
+			more = true
+			break
+		}
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	for i := range t.widths {
+		if t.widths[i] > t.o.MaxWidth {
+			t.widths[i] = t.o.MaxWidth
+		}
+	}
+
+	if t.o.Border {
+		t.rule("┌", "┬", "┐")
+	}
+	t.line(title)
+	if t.o.Border {
+		t.rule("├", "┼", "┤")
+	} else {
+		t.rule("", "", "")
+	}
+
+	n := 0
+	for _, cells := range rows {
+		t.line(cells)
+		n++
+	}
+
+	// second pass: stream the rest
+	for more && q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		t.line(q.tableRow())
+		n++
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	if t.o.Border {
+		t.rule("└", "┴", "┘")
+	}
+
+	if !t.o.NoFooter {
+		if n == 1 {
+			t.w.WriteString("(1 row)\n")
+		} else {
+			t.w.WriteString("(" + strconv.Itoa(n) + " rows)\n")
+		}
+	}
+
+	return t.w.Flush()
+}
@@ -0,0 +1,243 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// tx.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.04.18 Exist via Dialect
+// 2023.04.16 init
+// ----------------------------------------------------------------------------------
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Tx # transaction with the helper surface of DB
+type Tx struct {
+	*sql.Tx
+	db  *DB
+	Err error
+}
+
+// Begin # transaction with default options
+func (v *DB) Begin() (*Tx, error) {
+	return v.BeginTx(context.Background(), nil)
+}
+
+// BeginTx # opts.Isolation and opts.ReadOnly are mapped by the driver,
+// to the TPB on firebird and to SET TRANSACTION on mysql
+func (v *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := v.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx, db: v}, nil
+}
+
+// WithTx # commit if fn returns nil, rollback otherwise
+func (v *DB) WithTx(fn func(tx *Tx) error) error {
+	return v.WithTxContext(context.Background(), nil, fn)
+}
+
+// WithTxContext #
+func (v *DB) WithTxContext(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	tx, err := v.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DB # owner of the transaction
+func (t *Tx) DB() *DB {
+	return t.db
+}
+
+// CreateSqlx # running inside the transaction
+func (t *Tx) CreateSqlx() *SQLX {
+	q := NewSQLX(t.db)
+	q.cn = t.Tx
+	return q
+}
+
+// ScanI64Context #
+func (t *Tx) ScanI64Context(ctx context.Context, sq string, args ...interface{}) (int64, error) {
+	var n int64
+	err := scanRow(ctx, t.Tx, t.db.Dialect.Bind(), sq, args, &n)
+	return n, err
+}
+
+// ScanIContext #
+func (t *Tx) ScanIContext(ctx context.Context, sq string, args ...interface{}) (int, error) {
+	var n int
+	err := scanRow(ctx, t.Tx, t.db.Dialect.Bind(), sq, args, &n)
+	return n, err
+}
+
+// ScanSContext #
+func (t *Tx) ScanSContext(ctx context.Context, sq string, args ...interface{}) (string, error) {
+	var s string
+	err := scanRow(ctx, t.Tx, t.db.Dialect.Bind(), sq, args, &s)
+	return s, err
+}
+
+// ScanI64 # asInt64 with bind args
+func (t *Tx) ScanI64(sq string, args ...interface{}) (int64, error) {
+	return t.ScanI64Context(context.Background(), sq, args...)
+}
+
+// ScanI # asInteger with bind args
+func (t *Tx) ScanI(sq string, args ...interface{}) (int, error) {
+	return t.ScanIContext(context.Background(), sq, args...)
+}
+
+// ScanS # asString with bind args
+func (t *Tx) ScanS(sq string, args ...interface{}) (string, error) {
+	return t.ScanSContext(context.Background(), sq, args...)
+}
+
+// QueryI64 # asInt64 with bind args
+func (t *Tx) QueryI64(sq string, args ...interface{}) int64 {
+	var n int64
+	n, t.Err = t.ScanI64(sq, args...)
+	return n
+}
+
+// QueryI # asInteger with bind args
+func (t *Tx) QueryI(sq string, args ...interface{}) int {
+	var n int
+	n, t.Err = t.ScanI(sq, args...)
+	return n
+}
+
+// QueryS # asString with bind args
+func (t *Tx) QueryS(sq string, args ...interface{}) string {
+	var s string
+	s, t.Err = t.ScanS(sq, args...)
+	return s
+}
+
+// ExecI64 # asInt64
+func (t *Tx) ExecI64(format string, x ...interface{}) int64 {
+	return t.QueryI64(t.db.prepareSqText(format, x...))
+}
+
+// ExecI # asInteger
+func (t *Tx) ExecI(format string, x ...interface{}) int {
+	return t.QueryI(t.db.prepareSqText(format, x...))
+}
+
+// ExecS # asString
+func (t *Tx) ExecS(format string, x ...interface{}) string {
+	return t.QueryS(t.db.prepareSqText(format, x...))
+}
+
+// ExecQ #
+func (t *Tx) ExecQ(format string, x ...interface{}) *SQLX {
+	q := t.CreateSqlx()
+	q.Exec(t.db.prepareSqText(format, x...))
+	return q
+}
+
+// QueryQ # with bind args
+func (t *Tx) QueryQ(sq string, args ...interface{}) *SQLX {
+	q := t.CreateSqlx()
+	q.ExecArgs(sq, args...)
+	return q
+}
+
+// ExecuteQ # with bind args
+func (t *Tx) ExecuteQ(sq string, args ...interface{}) (*SQLX, error) {
+	return t.ExecuteQContext(context.Background(), sq, args...)
+}
+
+// ExecuteQContext #
+func (t *Tx) ExecuteQContext(ctx context.Context, sq string, args ...interface{}) (*SQLX, error) {
+	q := t.CreateSqlx()
+	if !q.ExecContext(ctx, sq, args...) {
+		return q, q.Err
+	}
+
+	return q, nil
+}
+
+// Execute # with bind args
+func (t *Tx) Execute(statement string, args ...interface{}) error {
+	return t.ExecuteContext(context.Background(), statement, args...)
+}
+
+// ExecuteContext #
+func (t *Tx) ExecuteContext(ctx context.Context, statement string, args ...interface{}) error {
+	return execute(ctx, t.Tx, t.db.Dialect.Bind(), statement, args)
+}
+
+// Exist # object of kind obj
+func (t *Tx) Exist(obj Object, sName string) bool {
+	var sq string
+	var args []interface{}
+
+	sq, args, t.Err = t.db.existArgs(obj, sName)
+	return t.Err == nil && t.QueryI(sq, args...) > 0
+}
+
+// ExistTable #
+func (t *Tx) ExistTable(sName string) bool {
+	return t.Exist(ObjTable, sName)
+}
+
+// ExistTableCol #
+func (t *Tx) ExistTableCol(sName string) bool {
+	return t.Exist(ObjTableCol, sName)
+}
+
+// ExistIndex #
+func (t *Tx) ExistIndex(sName string) bool {
+	return t.Exist(ObjIndex, sName)
+}
+
+// ExistProc #
+func (t *Tx) ExistProc(sName string) bool {
+	return t.Exist(ObjProc, sName)
+}
+
+// ExistFunc #
+func (t *Tx) ExistFunc(sName string) bool {
+	return t.Exist(ObjFunc, sName)
+}
+
+// ExistTrigger #
+func (t *Tx) ExistTrigger(sName string) bool {
+	return t.Exist(ObjTrigger, sName)
+}
+
+// ExistDomain #
+func (t *Tx) ExistDomain(sName string) bool {
+	return t.Exist(ObjDomain, sName)
+}
+
+// ExistException #
+func (t *Tx) ExistException(sName string) bool {
+	return t.Exist(ObjException, sName)
+}
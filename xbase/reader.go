@@ -0,0 +1,465 @@
+package xbase
+
+// ----------------------------------------------------------------------------------
+// reader.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 SkipUnknown, dBase III memo up to 1a 1a
+// 2023.05.09 init, memo of dBase III/IV (dbt) and FoxPro (fpt), Import
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/waldurbas/dbx"
+	"golang.org/x/text/encoding"
+)
+
+// Reader # records of a dbf file
+type Reader struct {
+	Fields []Field
+	Count  int
+
+	f         *os.File
+	br        *bufio.Reader
+	memo      *os.File
+	fpt       bool
+	blockSize int64
+	dec       *encoding.Decoder
+	recLen    int
+	n         int
+}
+
+// Open # dbf file, the memo file (.dbt, .fpt) is opened if the header says so
+func Open(fileName string, opts *Options) (*Reader, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{f: f, br: bufio.NewReader(f)}
+	if err = r.header(fileName, opts); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Reader) header(fileName string, opts *Options) error {
+	h := make([]byte, headerLen)
+	if _, err := io.ReadFull(r.br, h); err != nil {
+		return ErrFormat
+	}
+
+	r.Count = int(binary.LittleEndian.Uint32(h[4:]))
+	hdrLen := int(binary.LittleEndian.Uint16(h[8:]))
+	r.recLen = int(binary.LittleEndian.Uint16(h[10:]))
+	if hdrLen < headerLen+1 || r.recLen < 1 {
+		return ErrFormat
+	}
+
+	cp := codepageByID(h[29])
+	if opts != nil && opts.Codepage != "" {
+		var err error
+		if cp, err = codepageByName(opts.Codepage); err != nil {
+			return err
+		}
+	}
+	r.dec = cp.decoder()
+
+	// field descriptors up to 0x0D
+	pos := headerLen
+	recLen := 1
+	for pos+fieldLen <= hdrLen {
+		fd := make([]byte, fieldLen)
+		if _, err := io.ReadFull(r.br, fd[:1]); err != nil {
+			return ErrFormat
+		}
+		pos++
+
+		if fd[0] == endOfHeader {
+			break
+		}
+
+		if _, err := io.ReadFull(r.br, fd[1:]); err != nil {
+			return ErrFormat
+		}
+		pos += fieldLen - 1
+
+		name := fd[:maxNameLen+1]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+
+		f := Field{Name: strings.TrimSpace(string(name)), Type: fd[11], Len: int(fd[16]), Dec: int(fd[17])}
+		if f.Type == TypeChar {
+			// clipper: char fields > 255 use the decimal byte as high byte
+			f.Len += int(fd[17]) << 8
+			f.Dec = 0
+		}
+
+		r.Fields = append(r.Fields, f)
+		recLen += f.Len
+	}
+
+	if recLen != r.recLen {
+		return ErrFormat
+	}
+
+	if _, err := r.br.Discard(hdrLen - pos); err != nil {
+		return ErrFormat
+	}
+
+	switch h[0] {
+	case 0x83, 0x8B:
+		return r.openMemo(fileName, ".dbt", h[0] == 0x8B)
+	case 0xF5, 0x30:
+		for _, f := range r.Fields {
+			if f.Type == TypeMemo {
+				r.fpt = true
+				return r.openMemo(fileName, ".fpt", false)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Reader) openMemo(fileName string, ext string, dbase4 bool) error {
+	base := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+
+	var err error
+	for _, e := range []string{ext, strings.ToUpper(ext)} {
+		if r.memo, err = os.Open(base + e); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	r.blockSize = memoBlock
+	h := make([]byte, 32)
+	if _, err = r.memo.ReadAt(h, 0); err != nil {
+		return err
+	}
+
+	switch {
+	case r.fpt:
+		r.blockSize = int64(binary.BigEndian.Uint16(h[6:]))
+	case dbase4:
+		if bs := binary.LittleEndian.Uint16(h[20:]); bs > 0 {
+			r.blockSize = int64(bs)
+		}
+	}
+
+	if r.blockSize <= 0 {
+		r.blockSize = memoBlock
+	}
+
+	return nil
+}
+
+// readMemo # content of block blk
+func (r *Reader) readMemo(blk int64) ([]byte, error) {
+	off := blk * r.blockSize
+	h := make([]byte, 8)
+	if _, err := r.memo.ReadAt(h, off); err != nil {
+		return nil, err
+	}
+
+	// foxpro: type and length big endian, dBase IV: ff ff 08 00 and length
+	switch {
+	case r.fpt:
+		n := int64(binary.BigEndian.Uint32(h[4:]))
+		b := make([]byte, n)
+		_, err := r.memo.ReadAt(b, off+8)
+		return b, err
+
+	case h[0] == 0xFF && h[1] == 0xFF && h[2] == 0x08 && h[3] == 0x00:
+		n := int64(binary.LittleEndian.Uint32(h[4:])) - 8
+		if n < 0 {
+			return nil, ErrFormat
+		}
+		b := make([]byte, n)
+		_, err := r.memo.ReadAt(b, off+8)
+		return b, err
+	}
+
+	// dBase III: up to 1a 1a, a single 1a is data
+	var out []byte
+	buf := make([]byte, memoBlock)
+	eof := []byte{endOfFile, endOfFile}
+	for {
+		n, err := r.memo.ReadAt(buf, off)
+
+		from := len(out) - 1
+		if from < 0 {
+			from = 0
+		}
+
+		out = append(out, buf[:n]...)
+		if i := bytes.Index(out[from:], eof); i >= 0 {
+			return out[:from+i], nil
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return nil, err
+		}
+		off += int64(n)
+	}
+}
+
+func (r *Reader) decode(b []byte) string {
+	if r.dec == nil {
+		return string(b)
+	}
+
+	s, err := r.dec.Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+
+	return string(s)
+}
+
+// value # field content as bind value, nil for empty non char fields
+func (r *Reader) value(f *Field, b []byte) (interface{}, error) {
+	switch f.Type {
+	case TypeNumeric, TypeFloat:
+		s := strings.TrimSpace(string(b))
+		if s == "" || strings.Trim(s, "*") == "" {
+			return nil, nil
+		}
+
+		if f.Dec == 0 {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, fmt.Errorf("xbase: %s: bad number '%s'", f.Name, s)
+		}
+		return s, nil
+
+	case TypeDate:
+		s := strings.TrimSpace(string(b))
+		if len(s) != 8 || s == "00000000" {
+			return nil, nil
+		}
+		return s[:4] + "-" + s[4:6] + "-" + s[6:], nil
+
+	case TypeLogical:
+		switch b[0] {
+		case 'T', 't', 'Y', 'y':
+			return true, nil
+		case 'F', 'f', 'N', 'n':
+			return false, nil
+		}
+		return nil, nil
+
+	case TypeMemo:
+		var blk int64
+		if f.Len == 4 {
+			blk = int64(binary.LittleEndian.Uint32(b))
+		} else {
+			s := strings.TrimSpace(string(b))
+			if s == "" {
+				return nil, nil
+			}
+
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("xbase: %s: bad memo block '%s'", f.Name, s)
+			}
+			blk = n
+		}
+
+		if blk == 0 || r.memo == nil {
+			return nil, nil
+		}
+
+		m, err := r.readMemo(blk)
+		if err != nil {
+			return nil, fmt.Errorf("xbase: %s: %w", f.Name, err)
+		}
+		return r.decode(m), nil
+	}
+
+	return strings.TrimRight(r.decode(b), " "), nil
+}
+
+// Next # values of the next record, deleted records are skipped, io.EOF at end.
+// char and memo fields are string, numeric int64 or string, date YYYY-MM-DD, logical bool
+func (r *Reader) Next() ([]interface{}, error) {
+	rec := make([]byte, r.recLen)
+
+	for r.n < r.Count {
+		if _, err := io.ReadFull(r.br, rec); err != nil {
+			return nil, ErrFormat
+		}
+		r.n++
+
+		if rec[0] == '*' {
+			continue
+		}
+
+		vals := make([]interface{}, len(r.Fields))
+		pos := 1
+		for i := range r.Fields {
+			f := &r.Fields[i]
+
+			v, err := r.value(f, rec[pos:pos+f.Len])
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", r.n, err)
+			}
+			vals[i] = v
+			pos += f.Len
+		}
+
+		return vals, nil
+	}
+
+	return nil, io.EOF
+}
+
+// Close #
+func (r *Reader) Close() error {
+	var err error
+	if r.memo != nil {
+		err = r.memo.Close()
+		r.memo = nil
+	}
+
+	if r.f != nil {
+		if e := r.f.Close(); err == nil {
+			err = e
+		}
+		r.f = nil
+	}
+
+	return err
+}
+
+// ImportOptions #
+type ImportOptions struct {
+	Options
+	// Rename # dbf field -> table column, "" skips the field
+	Rename map[string]string
+	// Keys # upsert matching these columns instead of insert
+	Keys []string
+	// Batch # rows per insert statement, default 100
+	Batch int
+	// SkipUnknown # fields without table column are skipped instead of an error
+	SkipUnknown bool
+}
+
+// Import # records of fileName into table in one transaction,
+// fields are matched to the columns by name, returns the number of rows
+func Import(db *dbx.DB, fileName string, table string, opts *ImportOptions) (int, error) {
+	return ImportContext(context.Background(), db, fileName, table, opts)
+}
+
+// ImportContext #
+func ImportContext(ctx context.Context, db *dbx.DB, fileName string, table string, opts *ImportOptions) (int, error) {
+	o := ImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	r, err := Open(fileName, &o.Options)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var names []string
+	var fidx []int
+	for i, f := range r.Fields {
+		name := f.Name
+		for k, s := range o.Rename {
+			if strings.EqualFold(k, f.Name) {
+				name = s
+				break
+			}
+		}
+
+		if name != "" {
+			names = append(names, name)
+			fidx = append(fidx, i)
+		}
+	}
+
+	n := 0
+	err = db.WithTxContext(ctx, nil, func(tx *dbx.Tx) error {
+		known, err := tx.KnownCols(table, names, o.SkipUnknown)
+		if err != nil {
+			return err
+		}
+
+		var cols []string
+		var idx []int
+		for _, i := range known {
+			cols = append(cols, names[i])
+			idx = append(idx, fidx[i])
+		}
+
+		ins, err := tx.NewInserter(ctx, table, cols, o.Keys, o.Batch)
+		if err != nil {
+			return err
+		}
+
+		row := make([]interface{}, len(cols))
+		for {
+			vals, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return err
+			}
+
+			for c, i := range idx {
+				row[c] = vals[i]
+			}
+
+			if err = ins.Add(append([]interface{}{}, row...)...); err != nil {
+				return err
+			}
+		}
+
+		err = ins.Close()
+		n = ins.Count()
+
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,521 @@
+package xbase
+
+// ----------------------------------------------------------------------------------
+// writer.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 dBase IV memo blocks with length, char fields cut on a character boundary
+// 2023.05.09 init
+// ----------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	"github.com/waldurbas/dbx"
+	"golang.org/x/text/encoding"
+)
+
+// Writer # dbf file, memo file (.dbt) if there are memo fields
+type Writer struct {
+	Fields []Field
+
+	f      *os.File
+	bw     *bufio.Writer
+	memo   *os.File
+	enc    *encoding.Encoder
+	cp     *codepage
+	recLen int
+	n      int
+	block  uint32 // next free memo block
+}
+
+// fieldName # dbf name, upper case, max. 10 chars, unique
+func fieldName(name string, used map[string]bool) string {
+	n := strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name))
+
+	if n == "" || (n[0] >= '0' && n[0] <= '9') {
+		n = "F" + n
+	}
+
+	if len(n) > maxNameLen {
+		n = n[:maxNameLen]
+	}
+
+	base := n
+	for i := 1; used[n]; i++ {
+		sfx := strconv.Itoa(i)
+		if len(base)+len(sfx) > maxNameLen {
+			n = base[:maxNameLen-len(sfx)] + sfx
+		} else {
+			n = base + sfx
+		}
+	}
+	used[n] = true
+
+	return n
+}
+
+// FieldsOf # dbf fields for the columns of q
+func FieldsOf(q *dbx.SQLX) []Field {
+	used := map[string]bool{}
+	fs := make([]Field, len(q.Fields))
+
+	for i := range q.Fields {
+		sf := &q.Fields[i]
+		f := Field{Name: fieldName(sf.Name, used)}
+
+		switch sf.Typ {
+		case "SHORT", "SMALLINT", "TINYINT":
+			f.Type, f.Len = TypeNumeric, 6
+		case "INT", "INTEGER", "LONG", "MEDIUMINT":
+			f.Type, f.Len = TypeNumeric, 11
+		case "BIGINT", "INT64":
+			f.Type, f.Len = TypeNumeric, 20
+		case "DECIMAL", "NUMERIC":
+			f.Type, f.Len, f.Dec = TypeNumeric, 20, 4
+			if sf.Prec > 0 {
+				f.Len, f.Dec = sf.Prec+2, sf.Scale
+			}
+		case "DOUBLE", "FLOAT", "REAL":
+			f.Type, f.Len, f.Dec = TypeNumeric, 20, 6
+		case "DATE":
+			f.Type, f.Len = TypeDate, 8
+		case "TIMESTAMP", "DATETIME":
+			f.Type, f.Len = TypeChar, 19
+		case "BOOLEAN":
+			f.Type, f.Len = TypeLogical, 1
+		case "BLOB":
+			f.Type, f.Len = TypeMemo, 10
+		default:
+			f.Type, f.Len = TypeChar, sf.Len
+			if sf.Len <= 0 || sf.Len > maxCharLen {
+				f.Type, f.Len = TypeMemo, 10
+			}
+		}
+
+		if f.Len > 255 {
+			f.Len = 255
+		}
+		fs[i] = f
+	}
+
+	return fs
+}
+
+// memoName # file.dbf -> file.dbt
+func memoName(fileName string) string {
+	ext := filepath.Ext(fileName)
+	if ext == strings.ToUpper(ext) && ext != "" {
+		return strings.TrimSuffix(fileName, ext) + ".DBT"
+	}
+
+	return strings.TrimSuffix(fileName, ext) + ".dbt"
+}
+
+// Create # new dbf file with fields
+func Create(fileName string, fields []Field, opts *Options) (*Writer, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+
+	cp, err := codepageByName(o.Codepage)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{Fields: fields, cp: cp, enc: cp.encoder(), recLen: 1}
+
+	hasMemo := false
+	for _, f := range fields {
+		if f.Len < 1 || f.Len > 255 || f.Name == "" {
+			return nil, fmt.Errorf("xbase: bad field %s(%d)", f.Name, f.Len)
+		}
+
+		w.recLen += f.Len
+		if f.Type == TypeMemo {
+			hasMemo = true
+		}
+	}
+
+	if w.f, err = os.Create(fileName); err != nil {
+		return nil, err
+	}
+	w.bw = bufio.NewWriter(w.f)
+
+	if hasMemo {
+		if w.memo, err = os.Create(memoName(fileName)); err != nil {
+			w.f.Close()
+			return nil, err
+		}
+
+		// block 0 is the header
+		w.block = 1
+		if _, err = w.memo.Write(make([]byte, memoBlock)); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+
+	if err = w.header(time.Now()); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Writer) header(t time.Time) error {
+	h := make([]byte, headerLen)
+
+	h[0] = 0x03
+	if w.memo != nil {
+		h[0] = 0x8B
+	}
+
+	h[1], h[2], h[3] = byte(t.Year()-1900), byte(t.Month()), byte(t.Day())
+	binary.LittleEndian.PutUint32(h[4:], uint32(w.n))
+	binary.LittleEndian.PutUint16(h[8:], uint16(headerLen+fieldLen*len(w.Fields)+1))
+	binary.LittleEndian.PutUint16(h[10:], uint16(w.recLen))
+	h[29] = w.cp.ldid
+
+	w.bw.Write(h)
+
+	for _, f := range w.Fields {
+		fd := make([]byte, fieldLen)
+		copy(fd[:maxNameLen], f.Name)
+		fd[11] = f.Type
+		fd[16] = byte(f.Len)
+		fd[17] = byte(f.Dec)
+		w.bw.Write(fd)
+	}
+
+	return w.bw.WriteByte(endOfHeader)
+}
+
+func (w *Writer) encode(s string) []byte {
+	if w.enc == nil {
+		return []byte(s)
+	}
+
+	b, err := w.enc.Bytes([]byte(s))
+	if err != nil {
+		return []byte(s)
+	}
+
+	return b
+}
+
+// writeMemo # text into the next free blocks, returns the block number;
+// dBase IV block with ff ff 08 00 and length, binary data may contain 1a
+func (w *Writer) writeMemo(b []byte) (uint32, error) {
+	blk := w.block
+
+	h := []byte{0xFF, 0xFF, 0x08, 0x00, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(h[4:], uint32(len(b)+8))
+	b = append(h, b...)
+	if pad := len(b) % memoBlock; pad > 0 {
+		b = append(b, make([]byte, memoBlock-pad)...)
+	}
+
+	if _, err := w.memo.WriteAt(b, int64(blk)*memoBlock); err != nil {
+		return 0, err
+	}
+
+	w.block += uint32(len(b) / memoBlock)
+
+	return blk, nil
+}
+
+// fit # s encoded, cut on a character boundary to at most n bytes
+func (w *Writer) fit(s string, n int) []byte {
+	// every character takes at least one byte
+	if r := []rune(s); len(r) > n {
+		s = string(r[:n])
+	}
+
+	b := w.encode(s)
+	for len(b) > n {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+		b = w.encode(s)
+	}
+
+	return b
+}
+
+func fixed(s string, n int) []byte {
+	b := []byte(s)
+	if len(b) > n {
+		b = b[:n]
+	}
+
+	for len(b) < n {
+		b = append(b, ' ')
+	}
+
+	return b
+}
+
+func rightAligned(s string, n int) []byte {
+	if len(s) > n {
+		// does not fit, as dBase does
+		return []byte(strings.Repeat("*", n))
+	}
+
+	return []byte(strings.Repeat(" ", n-len(s)) + s)
+}
+
+// numeric # text of v with dec decimals
+func numeric(v interface{}, dec int) (string, error) {
+	switch x := v.(type) {
+	case int:
+		return numeric(int64(x), dec)
+	case int64:
+		if dec > 0 {
+			return decimal.NewFromInt(x).StringFixed(int32(dec)), nil
+		}
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', dec, 64), nil
+	case decimal.Decimal:
+		return x.StringFixed(int32(dec)), nil
+	case string:
+		d, err := decimal.NewFromString(strings.TrimSpace(x))
+		if err != nil {
+			return "", err
+		}
+		return d.StringFixed(int32(dec)), nil
+	}
+
+	return "", fmt.Errorf("xbase: no number %v", v)
+}
+
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05")
+	}
+
+	return fmt.Sprint(v)
+}
+
+// Write # one record, values nil, string, []byte, int64, float64,
+// decimal.Decimal, bool or time.Time
+func (w *Writer) Write(vals []interface{}) error {
+	if len(vals) != len(w.Fields) {
+		return fmt.Errorf("xbase: %d values for %d fields", len(vals), len(w.Fields))
+	}
+
+	rec := make([]byte, 1, w.recLen)
+	rec[0] = ' '
+
+	for i, f := range w.Fields {
+		v := vals[i]
+
+		switch {
+		case v == nil:
+			rec = append(rec, fixed("", f.Len)...)
+
+		case f.Type == TypeNumeric || f.Type == TypeFloat:
+			s, err := numeric(v, f.Dec)
+			if err != nil {
+				return fmt.Errorf("xbase: %s: %w", f.Name, err)
+			}
+			rec = append(rec, rightAligned(s, f.Len)...)
+
+		case f.Type == TypeDate:
+			s := ""
+			switch x := v.(type) {
+			case time.Time:
+				s = x.Format("20060102")
+			default:
+				s = strings.ReplaceAll(text(v), "-", "")
+			}
+			rec = append(rec, fixed(s, f.Len)...)
+
+		case f.Type == TypeLogical:
+			c := "?"
+			if b, ok := v.(bool); ok {
+				c = "F"
+				if b {
+					c = "T"
+				}
+			}
+			rec = append(rec, fixed(c, f.Len)...)
+
+		case f.Type == TypeMemo:
+			b, ok := v.([]byte)
+			if !ok {
+				b = w.encode(text(v))
+			}
+
+			s := ""
+			if len(b) > 0 {
+				if w.memo == nil {
+					return errors.New("xbase: no memo file")
+				}
+
+				blk, err := w.writeMemo(b)
+				if err != nil {
+					return err
+				}
+				s = strconv.FormatUint(uint64(blk), 10)
+			}
+			rec = append(rec, rightAligned(s, f.Len)...)
+
+		default:
+			rec = append(rec, fixed(string(w.fit(text(v), f.Len)), f.Len)...)
+		}
+	}
+
+	w.n++
+	_, err := w.bw.Write(rec)
+
+	return err
+}
+
+// Count # records written
+func (w *Writer) Count() int {
+	return w.n
+}
+
+// Close # end of file, record count into the header
+func (w *Writer) Close() error {
+	if w.f == nil {
+		return nil
+	}
+
+	err := w.bw.WriteByte(endOfFile)
+	if e := w.bw.Flush(); err == nil {
+		err = e
+	}
+
+	h := make([]byte, 4)
+	binary.LittleEndian.PutUint32(h, uint32(w.n))
+	if _, e := w.f.WriteAt(h, 4); err == nil {
+		err = e
+	}
+
+	if e := w.f.Close(); err == nil {
+		err = e
+	}
+	w.f = nil
+
+	if w.memo != nil {
+		// next free block and the block size of dBase IV
+		h = make([]byte, 22)
+		binary.LittleEndian.PutUint32(h, w.block)
+		binary.LittleEndian.PutUint16(h[20:], memoBlock)
+		if _, e := w.memo.WriteAt(h, 0); err == nil {
+			err = e
+		}
+
+		if e := w.memo.Close(); err == nil {
+			err = e
+		}
+		w.memo = nil
+	}
+
+	return err
+}
+
+// fieldValue # value of f for Write
+func fieldValue(f *dbx.SqxField, typ byte) interface{} {
+	if f.IsNull() {
+		return nil
+	}
+
+	switch typ {
+	case TypeNumeric, TypeFloat:
+		switch f.Typ {
+		case "DOUBLE", "FLOAT", "REAL":
+			if x, err := f.Float64(); err == nil {
+				return x
+			}
+		default:
+			if d, err := f.Decimal(); err == nil {
+				return d
+			}
+		}
+
+	case TypeDate:
+		if t, err := f.Time(); err == nil {
+			return t
+		}
+		return nil
+
+	case TypeLogical:
+		if b, err := f.Bool(); err == nil {
+			return b
+		}
+		return nil
+
+	case TypeMemo:
+		if f.Typ == "BLOB" {
+			return append([]byte{}, f.Value...)
+		}
+		return string(f.Value)
+	}
+
+	return f.AsString()
+}
+
+// Export # fetch all rows of q into a new dbf file, returns the number of records
+func Export(q *dbx.SQLX, fileName string, opts *Options) (int, error) {
+	fields := FieldsOf(q)
+
+	w, err := Create(fileName, fields, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	vals := make([]interface{}, len(fields))
+	for q.Fetch() {
+		if q.Err != nil {
+			break
+		}
+
+		for i := range q.Fields {
+			vals[i] = fieldValue(&q.Fields[i], fields[i].Type)
+		}
+
+		if err = w.Write(vals); err != nil {
+			w.Close()
+			return w.Count(), err
+		}
+	}
+
+	if q.Err != nil {
+		w.Close()
+		return w.Count(), q.Err
+	}
+
+	return w.Count(), w.Close()
+}
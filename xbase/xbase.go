@@ -0,0 +1,122 @@
+package xbase
+
+// ----------------------------------------------------------------------------------
+// xbase.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.09 init, dBase III dbf with dbt memo, codepages
+// ----------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// field types
+const (
+	TypeChar    = 'C'
+	TypeNumeric = 'N'
+	TypeFloat   = 'F'
+	TypeDate    = 'D'
+	TypeLogical = 'L'
+	TypeMemo    = 'M'
+)
+
+const (
+	headerLen   = 32
+	fieldLen    = 32
+	memoBlock   = 512
+	maxCharLen  = 254
+	maxNameLen  = 10
+	endOfHeader = 0x0D
+	endOfFile   = 0x1A
+)
+
+// ErrFormat #
+var ErrFormat = errors.New("xbase: bad dbf file")
+
+// Field # dbf field descriptor
+type Field struct {
+	Name string
+	Type byte
+	Len  int
+	Dec  int
+}
+
+// Options #
+type Options struct {
+	// Codepage # cp437, cp850, cp852, cp866, cp1250, cp1251, cp1252 or utf8,
+	// writer default cp1252, reader default from the file header
+	Codepage string
+}
+
+type codepage struct {
+	name string
+	ldid byte
+	cm   *charmap.Charmap
+}
+
+// codepages # language driver ids of the dbf header
+var codepages = []codepage{
+	{"cp437", 0x01, charmap.CodePage437},
+	{"cp850", 0x02, charmap.CodePage850},
+	{"cp1252", 0x03, charmap.Windows1252},
+	{"cp1252", 0x57, charmap.Windows1252},
+	{"cp852", 0x64, charmap.CodePage852},
+	{"cp866", 0x65, charmap.CodePage866},
+	{"cp1250", 0xC8, charmap.Windows1250},
+	{"cp1251", 0xC9, charmap.Windows1251},
+	{"utf8", 0x00, nil},
+}
+
+func codepageByName(name string) (*codepage, error) {
+	n := strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	if n == "" {
+		n = "cp1252"
+	}
+
+	for i := range codepages {
+		if codepages[i].name == n {
+			return &codepages[i], nil
+		}
+	}
+
+	return nil, errors.New("xbase: unknown codepage " + name)
+}
+
+func codepageByID(ldid byte) *codepage {
+	for i := range codepages {
+		if codepages[i].ldid == ldid {
+			return &codepages[i]
+		}
+	}
+
+	// unknown driver id, bytes as they are
+	return &codepages[len(codepages)-1]
+}
+
+func (c *codepage) encoder() *encoding.Encoder {
+	if c.cm == nil {
+		return nil
+	}
+
+	return encoding.ReplaceUnsupported(c.cm.NewEncoder())
+}
+
+func (c *codepage) decoder() *encoding.Decoder {
+	if c.cm == nil {
+		return nil
+	}
+
+	return c.cm.NewDecoder()
+}
@@ -0,0 +1,421 @@
+package dbx
+
+// ----------------------------------------------------------------------------------
+// xlsx.go for Go's dbx package
+// Copyright 2023 by Waldemar Urbas
+//-----------------------------------------------------------------------------------
+// This Source Code Form is subject to the terms of the 'MIT License'
+// A short and simple permissive license with conditions only requiring
+// preservation of copyright and license notices. Licensed works, modifications,
+// and larger works may be distributed under different terms and without source code.
+// ----------------------------------------------------------------------------------
+// HISTORY
+// ----------------------------------------------------------------------------------
+// 2023.05.17 sheets listed after they are written, text cut by characters
+// 2023.05.08 init, streaming xlsx workbook, inline strings
+// ----------------------------------------------------------------------------------
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// cell styles, see xlsxStyles
+const (
+	xfDefault = iota
+	xfHeader
+	xfDate
+	xfTimestamp
+	xfDecimal // + scale 0..9
+)
+
+const xlsxMaxScale = 9
+
+// xlsxEpoch # day 0 of the 1900 date system
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSXOptions #
+type XLSXOptions struct {
+	// Sheet # name of the sheet, default QName or Sheet1
+	Sheet string
+	// NoHeader # without the bold title row
+	NoHeader bool
+}
+
+// XLSXWriter # workbook with one sheet per query
+type XLSXWriter struct {
+	zw     *zip.Writer
+	sheets []string
+	parts  []int
+	nPart  int
+	closed bool
+}
+
+// NewXLSXWriter #
+func NewXLSXWriter(w io.Writer) *XLSXWriter {
+	return &XLSXWriter{zw: zip.NewWriter(w)}
+}
+
+// xlsxCol # column letters of index i (0 -> A)
+func xlsxCol(i int) string {
+	s := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+
+	return s
+}
+
+// sheetName # valid and unique sheet name
+func (x *XLSXWriter) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+
+	if name == "" {
+		name = "Sheet" + strconv.Itoa(len(x.sheets)+1)
+	}
+
+	if utf8.RuneCountInString(name) > 31 {
+		name = string([]rune(name)[:31])
+	}
+
+	base := name
+	for n := 2; ; n++ {
+		dup := false
+		for _, s := range x.sheets {
+			if strings.EqualFold(s, name) {
+				dup = true
+				break
+			}
+		}
+
+		if !dup {
+			return name
+		}
+
+		sfx := "_" + strconv.Itoa(n)
+		r := []rune(base)
+		if len(r)+len(sfx) > 31 {
+			r = r[:31-len(sfx)]
+		}
+		name = string(r) + sfx
+	}
+}
+
+func xlsxText(bw *bufio.Writer, ref string, style int, s string) {
+	// characters per cell
+	if utf8.RuneCountInString(s) > 32767 {
+		s = string([]rune(s)[:32767])
+	}
+
+	bw.WriteString(`<c r="` + ref + `"`)
+	if style != xfDefault {
+		bw.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	bw.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(bw, []byte(s))
+	bw.WriteString("</t></is></c>")
+}
+
+func xlsxNumber(bw *bufio.Writer, ref string, style int, v string) {
+	bw.WriteString(`<c r="` + ref + `"`)
+	if style != xfDefault {
+		bw.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	bw.WriteString("><v>" + v + "</v></c>")
+}
+
+// xlsxCell # typed cell of f, NULL is no cell
+func xlsxCell(bw *bufio.Writer, ref string, f *SqxField) {
+	if f.IsNull() {
+		return
+	}
+
+	switch f.Typ {
+	case "SHORT", "SMALLINT", "INT", "INTEGER", "LONG", "MEDIUMINT", "TINYINT", "BIGINT", "INT64":
+		if n, err := f.Int64(); err == nil {
+			xlsxNumber(bw, ref, xfDefault, strconv.FormatInt(n, 10))
+			return
+		}
+
+	case "DECIMAL", "NUMERIC":
+		if d, err := f.Decimal(); err == nil {
+			// scale unknown without precision (sqlite)
+			style := xfDefault
+			if f.Prec > 0 {
+				style = xfDecimal + f.Scale
+				if f.Scale > xlsxMaxScale {
+					style = xfDecimal + xlsxMaxScale
+				}
+			}
+			xlsxNumber(bw, ref, style, d.String())
+			return
+		}
+
+	case "DOUBLE", "FLOAT", "REAL":
+		if x, err := f.Float64(); err == nil {
+			xlsxNumber(bw, ref, xfDefault, strconv.FormatFloat(x, 'g', -1, 64))
+			return
+		}
+
+	case "BOOLEAN":
+		if b, err := f.Bool(); err == nil {
+			v := "0"
+			if b {
+				v = "1"
+			}
+			bw.WriteString(`<c r="` + ref + `" t="b"><v>` + v + "</v></c>")
+			return
+		}
+
+	case "DATE", "TIMESTAMP", "DATETIME":
+		if t, err := f.Time(); err == nil {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			days := t.Sub(xlsxEpoch).Hours() / 24
+			style := xfTimestamp
+			if f.Typ == "DATE" {
+				style = xfDate
+			}
+			xlsxNumber(bw, ref, style, strconv.FormatFloat(days, 'f', -1, 64))
+			return
+		}
+
+	case "BLOB":
+		if !utf8.Valid(f.Value) {
+			xlsxText(bw, ref, xfDefault, "["+strconv.Itoa(len(f.Value))+" bytes]")
+			return
+		}
+	}
+
+	xlsxText(bw, ref, xfDefault, string(f.Value))
+}
+
+// AddSheet # fetch all rows of q into a new sheet
+func (x *XLSXWriter) AddSheet(q *SQLX, opts *XLSXOptions) error {
+	if x.closed {
+		return errors.New("dbx: XLSXWriter is closed")
+	}
+
+	o := XLSXOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Sheet == "" {
+		o.Sheet = q.QName
+	}
+
+	// a failed sheet stays an unlisted part of the zip
+	x.nPart++
+	part := x.nPart
+
+	zf, err := x.zw.Create("xl/worksheets/sheet" + strconv.Itoa(part) + ".xml")
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(zf)
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if !o.NoHeader {
+		bw.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+
+	cols := make([]string, len(q.Fields))
+	if len(q.Fields) > 0 {
+		bw.WriteString("<cols>")
+		for i, f := range q.Fields {
+			cols[i] = xlsxCol(i)
+
+			wd := f.OutLen
+			if wd <= 0 {
+				wd = 10
+			}
+			if n := utf8.RuneCountInString(f.Name); n > wd {
+				wd = n
+			}
+			if wd > 60 {
+				wd = 60
+			}
+
+			n := strconv.Itoa(i + 1)
+			bw.WriteString(`<col min="` + n + `" max="` + n + `" width="` + strconv.Itoa(wd+2) + `" customWidth="1"/>`)
+		}
+		bw.WriteString("</cols>")
+	}
+
+	bw.WriteString("<sheetData>")
+
+	r := 0
+	if !o.NoHeader {
+		r++
+		bw.WriteString(`<row r="1">`)
+		for i, f := range q.Fields {
+			xlsxText(bw, cols[i]+"1", xfHeader, f.Name)
+		}
+		bw.WriteString("</row>")
+	}
+
+	for q.Fetch() {
+		if q.Err != nil {
+			return q.Err
+		}
+
+		r++
+		rs := strconv.Itoa(r)
+		bw.WriteString(`<row r="` + rs + `">`)
+		for i := range q.Fields {
+			xlsxCell(bw, cols[i]+rs, &q.Fields[i])
+		}
+		bw.WriteString("</row>")
+	}
+
+	if q.Err != nil {
+		return q.Err
+	}
+
+	bw.WriteString("</sheetData></worksheet>")
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+
+	x.sheets = append(x.sheets, x.sheetName(o.Sheet))
+	x.parts = append(x.parts, part)
+
+	return nil
+}
+
+func (x *XLSXWriter) file(name string, content string) error {
+	zf, err := x.zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(zf, content)
+	return err
+}
+
+func xlsxStyles() string {
+	var sb strings.Builder
+
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	sb.WriteString(`<numFmts count="` + strconv.Itoa(xlsxMaxScale+3) + `">`)
+	sb.WriteString(`<numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/>`)
+	sb.WriteString(`<numFmt numFmtId="165" formatCode="yyyy\-mm\-dd\ hh:mm:ss"/>`)
+	for sc := 0; sc <= xlsxMaxScale; sc++ {
+		fc := "#,##0"
+		if sc > 0 {
+			fc += "." + strings.Repeat("0", sc)
+		}
+		sb.WriteString(`<numFmt numFmtId="` + strconv.Itoa(166+sc) + `" formatCode="` + fc + `"/>`)
+	}
+	sb.WriteString(`</numFmts>`)
+
+	sb.WriteString(`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>`)
+	sb.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`)
+	sb.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	sb.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+
+	sb.WriteString(`<cellXfs count="` + strconv.Itoa(xfDecimal+xlsxMaxScale+1) + `">`)
+	sb.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	sb.WriteString(`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>`)
+	sb.WriteString(`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+	sb.WriteString(`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+	for sc := 0; sc <= xlsxMaxScale; sc++ {
+		sb.WriteString(`<xf numFmtId="` + strconv.Itoa(166+sc) + `" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+	}
+	sb.WriteString(`</cellXfs>`)
+
+	sb.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	sb.WriteString(`</styleSheet>`)
+
+	return sb.String()
+}
+
+// Close # writes workbook, styles and relations, w is not closed
+func (x *XLSXWriter) Close() error {
+	if x.closed {
+		return nil
+	}
+	x.closed = true
+
+	if len(x.sheets) == 0 {
+		x.nPart++
+		x.sheets = append(x.sheets, "Sheet1")
+		x.parts = append(x.parts, x.nPart)
+		if err := x.file("xl/worksheets/sheet"+strconv.Itoa(x.nPart)+".xml", xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`); err != nil {
+			return err
+		}
+	}
+
+	var ct, wb, rels strings.Builder
+
+	ct.WriteString(xml.Header)
+	ct.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	ct.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	ct.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	ct.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	ct.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	wb.WriteString(xml.Header)
+	wb.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+
+	rels.WriteString(xml.Header)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, name := range x.sheets {
+		n := strconv.Itoa(i + 1)
+		p := strconv.Itoa(x.parts[i])
+		ct.WriteString(`<Override PartName="/xl/worksheets/sheet` + p + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+
+		wb.WriteString(`<sheet name="`)
+		xml.EscapeText(&wb, []byte(name))
+		wb.WriteString(`" sheetId="` + n + `" r:id="rId` + n + `"/>`)
+
+		rels.WriteString(`<Relationship Id="rId` + n + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + p + `.xml"/>`)
+	}
+
+	ct.WriteString(`</Types>`)
+	wb.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`<Relationship Id="rId` + strconv.Itoa(len(x.sheets)+1) + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", ct.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", wb.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", xlsxStyles()},
+	}
+
+	for _, f := range files {
+		if err := x.file(f.name, f.content); err != nil {
+			return err
+		}
+	}
+
+	return x.zw.Close()
+}
+
+// WriteXLSX # fetch all rows into a workbook with one sheet
+func (q *SQLX) WriteXLSX(w io.Writer, opts *XLSXOptions) error {
+	x := NewXLSXWriter(w)
+	if err := x.AddSheet(q, opts); err != nil {
+		return err
+	}
+
+	return x.Close()
+}